@@ -14,18 +14,21 @@ import (
 	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc"
 
-	notificationpb "github.com/jiangqiao2/go-video-proto/proto/notification/notification"
 	notificationgrpc "notification-service/ddd/adapter/grpc"
 	_ "notification-service/ddd/adapter/http"
+	notificationkafka "notification-service/ddd/adapter/kafka"
 	"notification-service/ddd/application/app"
 	"notification-service/internal/resource"
 	"notification-service/pkg/config"
 	"notification-service/pkg/grpcutil"
+	"notification-service/pkg/kafkautil"
 	"notification-service/pkg/logger"
+	"notification-service/pkg/metrics"
 	"notification-service/pkg/middleware"
 	"notification-service/pkg/redisclient"
 	"notification-service/pkg/repository"
-	"notification-service/pkg/sse"
+	"notification-service/pkg/tracing"
+	notificationpb "notification-service/proto/notification"
 )
 
 // Run is the entrypoint of notification-service.
@@ -48,6 +51,14 @@ func Run() {
 	logger.SetGlobalLogger(logService)
 	logger.Infof("Notification service starting version=%s env=%s", "1.0.0", "development")
 
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing)
+	if err != nil {
+		logger.Fatal(fmt.Sprintf("Failed to initialize tracing error=%v", err))
+	}
+	if cfg.Tracing.Enabled {
+		logger.Infof("Tracing enabled exporter=%s sample_ratio=%g", cfg.Tracing.Exporter, cfg.Tracing.SampleRatio)
+	}
+
 	// Initialize database connection and expose it via internal resource package.
 	logger.Infof("Initializing database connection...")
 	db, err := repository.NewDatabase(&cfg.Database)
@@ -55,41 +66,87 @@ func Run() {
 		logger.Fatal(fmt.Sprintf("Failed to initialize database error=%v", err))
 	}
 	defer db.Close()
+	if err := db.Self.Use(metrics.GormPlugin()); err != nil {
+		logger.Warnf("Failed to install database metrics error=%v", err)
+	}
+	if err := db.Self.Use(tracing.GormPlugin()); err != nil {
+		logger.Warnf("Failed to install database tracing error=%v", err)
+	}
 	resource.SetMainDB(db.Self)
 	logger.Infof("Database connected")
 
-	// Initialize Redis client (optional). If initialization fails we log it
-	// and continue with process-local notifications only.
-	logger.Infof("Initializing Redis client...")
-	redisCli, err := redisclient.New(cfg.Redis)
-	if err != nil {
-		logger.Errorf("Failed to initialize redis; SSE notifications will be local-only error=%v", err)
+	// Relay transactional outbox events (written together with notifications)
+	// to the SSE bridge with retries.
+	outboxRelay := app.DefaultOutboxRelay()
+	outboxRelay.Start()
+
+	// Rate limits for notification creation; must be installed before the
+	// application service is built.
+	limiter := startRateLimit(cfg.RateLimit)
+	notificationApp := app.DefaultNotificationApp()
+
+	// Consume domain events from other go-video services (optional).
+	var eventConsumer *notificationkafka.NotificationConsumer
+	if topics := notificationkafka.Topics(cfg.Kafka.Consumer); cfg.Kafka.Enabled && len(topics) > 0 {
+		var dlq kafkautil.Writer
+		if cfg.Kafka.Consumer.DeadLetterTopic != "" {
+			dlq = kafkautil.NewWriter(cfg.Kafka)
+		}
+		eventConsumer, err = notificationkafka.NewNotificationConsumer(
+			notificationApp,
+			kafkautil.NewGroupReader(cfg.Kafka, topics),
+			dlq,
+			cfg.Kafka.Consumer,
+		)
+		if err != nil {
+			logger.Fatal(fmt.Sprintf("Failed to initialize kafka consumer error=%v", err))
+		}
+		eventConsumer.Start()
 	} else {
-		defer func() {
-			logger.Infof("Closing Redis client...")
-			_ = redisCli.Close()
-		}()
-		// Bridge in-memory SSE hub to Redis Pub/Sub for cross-instance fanout.
-		sse.InitRedisPubSub(redisCli.Raw(), "")
+		logger.Infof("Kafka consumer disabled or no routes configured")
 	}
 
+	// Producer identities for notification creation over HTTP and gRPC; must
+	// be installed before the controllers register their routes. Nonces of
+	// signed requests are recorded in Redis once it attaches.
+	producers := startProducerAuth(cfg.ProducerAuth)
+
+	// Initialize Redis client (optional). If Redis is unreachable we keep
+	// retrying in the background and serve process-local notifications until
+	// the bridge attaches.
+	logger.Infof("Initializing Redis client...")
+	sseBridge := startSSEBridge(cfg.SSE, cfg.Redis.Host != "")
+	redisConn := startRedis(cfg.Redis, func(cli *redisclient.Client) {
+		sseBridge.attachRedis(cli)
+		if limiter != nil {
+			limiter.Attach(cli.Raw())
+		}
+		if producers != nil {
+			producers.Attach(cli.Raw())
+		}
+	})
+	defer redisConn.Close()
+	defer sseBridge.Close()
+
+	// Readiness checks for the probes and the gRPC health service.
+	probe := startHealth(cfg.Health, db, redisConn)
+
 	// Create Gin engine and common middlewares.
 	logger.Infof("Creating HTTP routes...")
 	router := gin.New()
 	router.Use(
 		gin.Recovery(),
 		middleware.RequestContextMiddleware(),
+		middleware.TracingMiddleware(),
 		middleware.RequestLogMiddleware(),
+		middleware.MetricsMiddleware(),
 	)
 
-	// Health check endpoint.
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":    "ok",
-			"service":   "notification-service",
-			"timestamp": time.Now().Unix(),
-		})
-	})
+	// Prometheus scrape endpoint.
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// Liveness and readiness probes.
+	probe.routes(router)
 
 	// Register all controllers via shared manager package.
 	logger.Infof("Registering routes...")
@@ -130,18 +187,33 @@ func Run() {
 			logger.Fatal(fmt.Sprintf("Failed to listen on gRPC port address=%s error=%v", grpcAddr, err))
 		}
 
-		grpcServer = grpc.NewServer(
-			grpc.ChainUnaryInterceptor(
-				grpcutil.UnaryServerRequestIDInterceptor,
-			),
+		interceptors := []grpc.UnaryServerInterceptor{
+			grpcutil.UnaryServerTracingInterceptor,
+			grpcutil.UnaryServerRequestIDInterceptor,
+			grpcutil.UnaryServerMetricsInterceptor,
+		}
+		if producers != nil {
+			interceptors = append(interceptors, grpcutil.UnaryServerProducerAuthInterceptor(producers,
+				notificationpb.NotificationService_CreateNotification_FullMethodName))
+		}
+		serverOpts := []grpc.ServerOption{
+			grpc.ChainUnaryInterceptor(interceptors...),
 			grpc.MaxRecvMsgSize(cfg.GRPC.MaxRecvMsgSize),
 			grpc.MaxSendMsgSize(cfg.GRPC.MaxSendMsgSize),
-		)
+		}
+		creds, err := grpcCredentials(cfg.GRPC.TLS)
+		if err != nil {
+			logger.Fatal(fmt.Sprintf("Failed to load gRPC TLS config error=%v", err))
+		}
+		if creds != nil {
+			serverOpts = append(serverOpts, creds)
+		}
+		grpcServer = grpc.NewServer(serverOpts...)
 
-		notificationApp := app.DefaultNotificationApp()
 		notificationpb.RegisterNotificationServiceServer(grpcServer, notificationgrpc.NewNotificationGrpcServer(notificationApp))
-
+		probe.serveGRPC(grpcServer)
 		go func() {
+			defer probe.grpcServing.Store(false)
 			if err := grpcServer.Serve(grpcListener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
 				logger.Errorf("Notification gRPC server exited unexpectedly error=%v", err)
 			}
@@ -152,6 +224,9 @@ func Run() {
 		logger.Warnf("gRPC port is not configured, skipping notification gRPC server startup")
 	}
 
+	// Make the gRPC endpoint discoverable by other go-video services.
+	registration := startServiceRegistry(cfg.ServiceRegistry, cfg.GRPC.Port)
+
 	// Start HTTP server with graceful shutdown.
 	port := fmt.Sprintf(":%d", cfg.Server.Port)
 	server := &http.Server{
@@ -166,7 +241,7 @@ func Run() {
 		}
 	}()
 
-	logger.Infof("HTTP server started port=%s health_url=%s", port, fmt.Sprintf("http://localhost%s/health", port))
+	logger.Infof("HTTP server started port=%s readiness_url=%s", port, fmt.Sprintf("http://localhost%s/readyz", port))
 
 	// Wait for termination signal.
 	quit := make(chan os.Signal, 1)
@@ -175,6 +250,17 @@ func Run() {
 
 	logger.Infof("Received shutdown signal, shutting down server...")
 
+	// Stop being discovered and fail readiness first, so clients and load
+	// balancers stop routing here while the streams drain.
+	deregisterCtx, cancelDeregister := context.WithTimeout(context.Background(), 5*time.Second)
+	registration.Deregister(deregisterCtx)
+	cancelDeregister()
+	probe.StartDraining()
+
+	// SSE handlers only return when their client goes away, so close the
+	// streams first or server.Shutdown below would time out.
+	sseBridge.Drain()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -190,6 +276,18 @@ func Run() {
 		logger.Fatal(fmt.Sprintf("Server forced to close error=%v", err))
 	}
 
+	if eventConsumer != nil {
+		logger.Infof("Stopping kafka consumer...")
+		eventConsumer.Stop()
+	}
+
+	logger.Infof("Stopping outbox relay...")
+	outboxRelay.Stop()
+
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Warnf("Failed to flush traces error=%v", err)
+	}
+
 	logger.Infof("Server exited safely")
 
 	if logService != nil {
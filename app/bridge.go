@@ -0,0 +1,172 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"golang.org/x/time/rate"
+
+	"notification-service/pkg/config"
+	"notification-service/pkg/logger"
+	"notification-service/pkg/redisclient"
+	"notification-service/pkg/sse"
+)
+
+// sseBridge installs the configured SSE hub, selects the broker behind
+// sse.PublishNotification and attaches it once its dependencies are
+// reachable. Redis backends wait for Redis; NATS attaches right away and is re-attached with the presence
+// directory when Redis comes up and targeted routing is enabled.
+type sseBridge struct {
+	cfg  config.SSEConfig
+	nats *nats.Conn
+}
+
+func signingOptions(cfg config.SSESigningConfig) sse.SigningOptions {
+	opts := sse.SigningOptions{MaxAge: cfg.MaxAge, AllowUnsigned: cfg.AllowUnsigned}
+	for _, k := range cfg.Keys {
+		opts.Keys = append(opts.Keys, sse.SigningKey{ID: k.ID, Secret: []byte(k.Secret)})
+	}
+	return opts
+}
+
+// startSSEBridge sets up the SSE hub and policies. redisConfigured reports
+// whether a Redis host is set; without it a Redis broker cannot attach and
+// the instance runs single-instance, delivering SSE events locally.
+func startSSEBridge(cfg config.SSEConfig, redisConfigured bool) *sseBridge {
+	hub, err := sse.NewHubOfKind(cfg.Hub)
+	if err != nil {
+		logger.Fatal(fmt.Sprintf("Invalid SSE hub config error=%v", err))
+	}
+	sse.SetDefaultHub(hub)
+
+	action, ok := sse.ParseOverflowAction(cfg.Overflow.Action)
+	if !ok {
+		logger.Fatal(fmt.Sprintf("Invalid SSE overflow action %q", cfg.Overflow.Action))
+	}
+	sse.SetDefaultOverflowPolicy(sse.OverflowPolicy{
+		Capacity:        cfg.Overflow.Capacity,
+		Action:          action,
+		DisconnectAfter: cfg.Overflow.DisconnectAfter,
+	})
+
+	onUserLimit, ok := sse.ParseUserLimitAction(cfg.Admission.OnUserLimit)
+	if !ok {
+		logger.Fatal(fmt.Sprintf("Invalid SSE admission on_user_limit %q", cfg.Admission.OnUserLimit))
+	}
+	sse.SetAdmissionPolicy(sse.AdmissionPolicy{
+		MaxPerInstance: cfg.Admission.MaxPerInstance,
+		MaxPerUser:     cfg.Admission.MaxPerUser,
+		OnUserLimit:    onUserLimit,
+		ReconnectRate:  rate.Limit(cfg.Admission.ReconnectRate),
+		ReconnectBurst: cfg.Admission.ReconnectBurst,
+		RetryAfter:     cfg.Admission.RetryAfter,
+	})
+
+	if err := signingOptions(cfg.Signing).Validate(); err != nil {
+		logger.Fatal(fmt.Sprintf("Invalid SSE signing config error=%v", err))
+	}
+	if len(cfg.Signing.Keys) == 0 {
+		logger.Warnf("SSE bridge envelopes are not signed; any broker client can push events to SSE streams")
+	}
+
+	b := &sseBridge{cfg: cfg}
+	switch cfg.Broker {
+	case sse.BrokerRedisPubSub, sse.BrokerRedisStreams:
+		// Until Redis attaches, reliable deliveries fail and are retried
+		// rather than reaching only this instance.
+		sse.SetBridgeExpected(redisConfigured)
+		return b
+	case sse.BrokerNATS:
+		sse.SetBridgeExpected(true)
+	default:
+		logger.Errorf("Unknown SSE broker %q, using %s", cfg.Broker, sse.BrokerRedisPubSub)
+		b.cfg.Broker = sse.BrokerRedisPubSub
+		sse.SetBridgeExpected(redisConfigured)
+		return b
+	}
+
+	// The client keeps retrying in the background, like the Redis attacher.
+	conn, err := nats.Connect(cfg.NATS.URL,
+		nats.Name(cfg.NATS.Name),
+		nats.MaxReconnects(-1),
+		nats.RetryOnFailedConnect(true),
+	)
+	if err != nil {
+		logger.Errorf("Failed to initialize NATS; SSE notifications are local-only url=%s error=%v", cfg.NATS.URL, err)
+		return b
+	}
+	b.nats = conn
+	sse.InitBridge(sse.NewNATSBroker(conn), b.options(nil))
+	logger.Infof("SSE bridge attached broker=%s", sse.BrokerNATS)
+	return b
+}
+
+func (b *sseBridge) options(cli *redisclient.Client) sse.BridgeOptions {
+	opts := sse.BridgeOptions{
+		TargetedRouting: b.cfg.TargetedRouting,
+		PresenceTTL:     b.cfg.PresenceTTL,
+		Signing:         signingOptions(b.cfg.Signing),
+	}
+	if cli != nil {
+		opts.Presence = cli.Raw()
+	}
+	return opts
+}
+
+// attachRedis is called by the Redis attacher once Redis is reachable.
+func (b *sseBridge) attachRedis(cli *redisclient.Client) {
+	switch b.cfg.Broker {
+	case sse.BrokerNATS:
+		if b.nats == nil || !b.cfg.TargetedRouting {
+			return
+		}
+		sse.InitBridge(sse.NewNATSBroker(b.nats), b.options(cli))
+	case sse.BrokerRedisStreams:
+		broker := sse.NewRedisStreamsBroker(cli.Raw(), sse.RedisStreamsOptions{
+			MaxLen:    b.cfg.StreamMaxLen,
+			Group:     b.cfg.StreamGroup,
+			Retention: b.cfg.StreamRetention,
+		})
+		opts := b.options(cli)
+		// Entries replayed after a restart are up to StreamRetention old.
+		opts.Signing.MaxAge = max(opts.Signing.MaxAge, b.cfg.StreamRetention)
+		sse.InitBridge(broker, opts)
+	default:
+		sse.InitBridge(sse.NewRedisPubSubBroker(cli.Raw()), b.options(cli))
+	}
+	logger.Infof("SSE bridge attached broker=%s", b.cfg.Broker)
+}
+
+// Close detaches the bridge and closes the NATS connection, if any.
+func (b *sseBridge) Close() {
+	sse.CloseBridge()
+	if b.nats != nil {
+		b.nats.Close()
+	}
+}
+
+// Drain stops admitting SSE streams and closes the connected ones in waves
+// so clients reconnect to other instances before the HTTP server shuts down.
+func (b *sseBridge) Drain() {
+	cfg := b.cfg.Drain
+	sse.StartDraining()
+	hub := sse.DefaultHub()
+	logger.Infof("Draining SSE streams connections=%d pre_stop_delay=%s", hub.ConnectionCount(), cfg.PreStopDelay)
+	time.Sleep(cfg.PreStopDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+	err := sse.Drain(ctx, hub, sse.DrainOptions{
+		Waves:        cfg.Waves,
+		WaveInterval: cfg.WaveInterval,
+		RetryMin:     cfg.RetryMin,
+		RetryMax:     cfg.RetryMax,
+	})
+	if err != nil {
+		logger.Warnf("SSE drain did not finish connections=%d error=%v", hub.ConnectionCount(), err)
+		return
+	}
+	logger.Infof("SSE streams drained")
+}
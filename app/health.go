@@ -0,0 +1,187 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"notification-service/pkg/config"
+	"notification-service/pkg/health"
+	"notification-service/pkg/logger"
+	"notification-service/pkg/repository"
+	"notification-service/pkg/sse"
+	notificationpb "notification-service/proto/notification"
+)
+
+const serviceName = "notification-service"
+
+// healthProbe serves the liveness and readiness probes over HTTP and the
+// standard gRPC health service. Readiness fails as soon as shutdown starts
+// draining, so load balancers stop routing here before streams are closed.
+type healthProbe struct {
+	cfg     config.HealthConfig
+	checker *health.Checker
+
+	// grpcServing is set while the gRPC server accepts calls.
+	grpcServing atomic.Bool
+	grpcHealth  *grpchealth.Server
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func startHealth(cfg config.HealthConfig, db *repository.Database, redis *redisAttacher) *healthProbe {
+	p := &healthProbe{
+		cfg:     cfg,
+		checker: health.NewChecker(cfg.Timeout),
+	}
+	p.register("db", func(ctx context.Context) error {
+		sqlDB, err := db.Self.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+	p.register("redis", func(ctx context.Context) error {
+		cli := redis.Client()
+		if cli == nil {
+			return errors.New("not connected")
+		}
+		return cli.Raw().Ping(ctx).Err()
+	})
+	p.register("bridge", func(context.Context) error {
+		st, attached := sse.CurrentBridgeStatus()
+		if !attached {
+			return errors.New("not attached")
+		}
+		if st.State != sse.BridgeConnected.String() {
+			return fmt.Errorf("subscriber %s: %s", st.State, st.LastError)
+		}
+		return nil
+	})
+	return p
+}
+
+func (p *healthProbe) register(name string, fn health.CheckFunc) {
+	p.checker.Register(name, fn, slices.Contains(p.cfg.Optional, name))
+}
+
+// routes registers /livez, /readyz and /health. /health keeps its original
+// meaning for existing probes: it checks no dependencies and only fails
+// while draining, so a database outage does not get healthy pods restarted.
+func (p *healthProbe) routes(router *gin.Engine) {
+	router.GET("/livez", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status":    "ok",
+			"service":   serviceName,
+			"timestamp": time.Now().Unix(),
+		})
+	})
+	router.GET("/health", func(c *gin.Context) {
+		if sse.Draining() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":    "draining",
+				"service":   serviceName,
+				"timestamp": time.Now().Unix(),
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status":    "ok",
+			"service":   serviceName,
+			"timestamp": time.Now().Unix(),
+		})
+	})
+	router.GET("/readyz", p.readyz)
+}
+
+func (p *healthProbe) readyz(c *gin.Context) {
+	if sse.Draining() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":    "draining",
+			"service":   serviceName,
+			"timestamp": time.Now().Unix(),
+		})
+		return
+	}
+	report := p.checker.Run(c.Request.Context())
+	code := http.StatusOK
+	if !report.Ready() {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{
+		"status":    report.Status,
+		"service":   serviceName,
+		"timestamp": time.Now().Unix(),
+		"checks":    report.Checks,
+	})
+}
+
+// serveGRPC registers the gRPC health service on s and keeps its status in
+// line with the readiness checks, which from now on include the gRPC server.
+// It is called right before s starts serving, which it records first so the
+// initial refresh does not report NOT_SERVING until the next interval.
+func (p *healthProbe) serveGRPC(s *grpc.Server) {
+	p.grpcServing.Store(true)
+	p.register("grpc", func(context.Context) error {
+		if !p.grpcServing.Load() {
+			return errors.New("not serving")
+		}
+		return nil
+	})
+	p.grpcHealth = grpchealth.NewServer()
+	healthpb.RegisterHealthServer(s, p.grpcHealth)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+	go p.refresh(ctx)
+}
+
+func (p *healthProbe) refresh(ctx context.Context) {
+	defer close(p.done)
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		status := healthpb.HealthCheckResponse_SERVING
+		report := p.checker.Run(ctx)
+		if !report.Ready() {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		if status != last && ctx.Err() == nil {
+			if status == healthpb.HealthCheckResponse_NOT_SERVING {
+				logger.Warnf("Readiness checks failing, reporting NOT_SERVING checks=%v", report.Checks)
+			}
+			p.grpcHealth.SetServingStatus("", status)
+			p.grpcHealth.SetServingStatus(notificationpb.NotificationService_ServiceDesc.ServiceName, status)
+			last = status
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// StartDraining fails readiness over HTTP and gRPC for the rest of the
+// process lifetime.
+func (p *healthProbe) StartDraining() {
+	sse.StartDraining()
+	if p.grpcHealth == nil {
+		return
+	}
+	p.cancel()
+	<-p.done
+	p.grpcHealth.Shutdown()
+}
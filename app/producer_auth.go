@@ -0,0 +1,72 @@
+package app
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"notification-service/pkg/config"
+	"notification-service/pkg/logger"
+	"notification-service/pkg/producerauth"
+)
+
+// startProducerAuth installs the producer registry used by the HTTP
+// middleware and the gRPC interceptor; it returns nil when disabled.
+func startProducerAuth(cfg config.ProducerAuthConfig) *producerauth.Registry {
+	if !cfg.Enabled {
+		logger.Warnf("Producer authentication is disabled; any caller can create notifications")
+		return nil
+	}
+	producers := make([]producerauth.Producer, 0, len(cfg.Producers))
+	for _, p := range cfg.Producers {
+		producers = append(producers, producerauth.Producer{
+			Name:       p.Name,
+			APIKeys:    p.APIKeys,
+			Secrets:    p.HMACSecrets,
+			Identities: p.Identities,
+			Types:      p.Types,
+		})
+	}
+	reg, err := producerauth.NewRegistry(producers, cfg.MaxSkew)
+	if err != nil {
+		logger.Fatal(fmt.Sprintf("Invalid producer auth config error=%v", err))
+	}
+	if len(producers) == 0 {
+		logger.Warnf("Producer authentication is enabled without producers; notification creation is rejected")
+	}
+	producerauth.SetDefaultRegistry(reg)
+	logger.Infof("Producer authentication enabled producers=%d", len(producers))
+	return reg
+}
+
+// grpcCredentials returns the TLS server option for cfg, or nil for
+// plaintext. With a client CA, client certificates are verified when
+// presented so producers can authenticate by mTLS identity.
+func grpcCredentials(cfg config.GRPCTLSConfig) (grpc.ServerOption, error) {
+	if cfg.CertFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsCfg := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates in client CA file")
+		}
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return grpc.Creds(credentials.NewTLS(tlsCfg)), nil
+}
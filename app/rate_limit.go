@@ -0,0 +1,42 @@
+package app
+
+import (
+	"fmt"
+
+	"notification-service/pkg/config"
+	"notification-service/pkg/logger"
+	"notification-service/pkg/ratelimit"
+)
+
+// startRateLimit installs the notification rate limiter used by
+// NotificationApp.Create; it returns nil when disabled. The limiter allows
+// everything until Redis attaches.
+func startRateLimit(cfg config.RateLimitConfig) *ratelimit.Limiter {
+	if !cfg.Enabled || len(cfg.Rules) == 0 {
+		logger.Infof("Notification rate limiting disabled")
+		return nil
+	}
+	rules := make([]ratelimit.Rule, 0, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		action, ok := ratelimit.ParseAction(r.Action)
+		if !ok {
+			logger.Fatal(fmt.Sprintf("Invalid rate limit action rule=%s action=%s", r.Name, r.Action))
+		}
+		rules = append(rules, ratelimit.Rule{
+			Name:     r.Name,
+			Scope:    ratelimit.Scope(r.Scope),
+			Limit:    r.Limit,
+			Window:   r.Window,
+			Action:   action,
+			Type:     r.Type,
+			Producer: r.Producer,
+		})
+	}
+	limiter, err := ratelimit.New(rules)
+	if err != nil {
+		logger.Fatal(fmt.Sprintf("Invalid rate limit config error=%v", err))
+	}
+	ratelimit.SetDefault(limiter)
+	logger.Infof("Notification rate limiting enabled rules=%d", len(rules))
+	return limiter
+}
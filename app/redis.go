@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"notification-service/pkg/config"
+	"notification-service/pkg/logger"
+	"notification-service/pkg/redisclient"
+)
+
+const (
+	redisAttachMinBackoff = time.Second
+	redisAttachMaxBackoff = 30 * time.Second
+)
+
+// redisAttacher owns the Redis client for the SSE bridge. If Redis is not
+// reachable at startup it keeps retrying in the background and calls
+// onAttach once a connection succeeds, so the instance does not stay
+// local-only until the next restart.
+type redisAttacher struct {
+	cfg      config.RedisConfig
+	onAttach func(cli *redisclient.Client)
+
+	mu     sync.RWMutex
+	client *redisclient.Client
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startRedis connects to Redis and calls onAttach, retrying in the
+// background on failure.
+func startRedis(cfg config.RedisConfig, onAttach func(cli *redisclient.Client)) *redisAttacher {
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &redisAttacher{
+		cfg:      cfg,
+		onAttach: onAttach,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	cli, err := redisclient.New(cfg)
+	if err == nil {
+		a.attach(cli)
+		close(a.done)
+		return a
+	}
+
+	logger.Errorf("Failed to initialize redis; SSE notifications are local-only until it becomes reachable error=%v", err)
+	go a.retry(ctx)
+	return a
+}
+
+func (a *redisAttacher) retry(ctx context.Context) {
+	defer close(a.done)
+
+	backoff := redisAttachMinBackoff
+	for {
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+
+		cli, err := redisclient.New(a.cfg)
+		if err == nil {
+			a.attach(cli)
+			return
+		}
+		logger.Warnf("Redis still unreachable retry_in=%s error=%v", backoff, err)
+		if backoff *= 2; backoff > redisAttachMaxBackoff {
+			backoff = redisAttachMaxBackoff
+		}
+	}
+}
+
+func (a *redisAttacher) attach(cli *redisclient.Client) {
+	a.mu.Lock()
+	a.client = cli
+	a.mu.Unlock()
+	logger.Infof("Redis connected")
+	if a.onAttach != nil {
+		a.onAttach(cli)
+	}
+}
+
+// Client returns the Redis client, or nil while Redis is not yet reachable.
+func (a *redisAttacher) Client() *redisclient.Client {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.client
+}
+
+// Close stops background retries and closes the client.
+func (a *redisAttacher) Close() {
+	a.cancel()
+	<-a.done
+	if cli := a.Client(); cli != nil {
+		logger.Infof("Closing Redis client...")
+		_ = cli.Close()
+	}
+}
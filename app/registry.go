@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"notification-service/pkg/config"
+	"notification-service/pkg/logger"
+	"notification-service/pkg/registry"
+)
+
+// serviceRegistration keeps the gRPC endpoint registered in etcd while the
+// server is up.
+type serviceRegistration struct {
+	cli      *clientv3.Client
+	registry registry.Registry
+	inst     registry.Instance
+}
+
+// startServiceRegistry registers the gRPC server listening on grpcPort. It
+// returns nil when registration is disabled or not configured; an
+// unreachable etcd only delays the registration, which is retried in the
+// background.
+func startServiceRegistry(cfg config.ServiceRegistryConfig, grpcPort int) *serviceRegistration {
+	if !cfg.Enabled {
+		return nil
+	}
+	if len(cfg.Endpoints) == 0 {
+		logger.Warnf("Service registry enabled without etcd endpoints, skipping registration")
+		return nil
+	}
+	if grpcPort <= 0 {
+		logger.Warnf("Service registry enabled without a gRPC port, skipping registration")
+		return nil
+	}
+
+	host := cfg.RegisterHost
+	if host == "" {
+		ip, err := registry.LocalIP()
+		if err != nil {
+			logger.Errorf("Failed to determine register host, skipping registration error=%v", err)
+			return nil
+		}
+		host = ip
+	}
+	id := cfg.ServiceID
+	if id == "" {
+		id = registry.DefaultInstanceID(cfg.ServiceName)
+	}
+
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   cfg.Endpoints,
+		DialTimeout: cfg.DialTimeout,
+	})
+	if err != nil {
+		logger.Errorf("Failed to create etcd client, skipping registration error=%v", err)
+		return nil
+	}
+
+	r := &serviceRegistration{
+		cli: cli,
+		registry: registry.NewEtcdRegistry(cli, registry.EtcdOptions{
+			Prefix:          cfg.Prefix,
+			TTL:             cfg.TTL,
+			RefreshInterval: cfg.RefreshInterval,
+		}),
+		inst: registry.Instance{
+			Name: cfg.ServiceName,
+			ID:   id,
+			Addr: net.JoinHostPort(host, strconv.Itoa(grpcPort)),
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DialTimeout)
+	defer cancel()
+	if err := r.registry.Register(ctx, r.inst); err != nil {
+		logger.Errorf("Failed to register service, retrying in background service=%s id=%s error=%v", r.inst.Name, r.inst.ID, err)
+	} else {
+		logger.Infof("Service registered service=%s id=%s addr=%s prefix=%s", r.inst.Name, r.inst.ID, r.inst.Addr, cfg.Prefix)
+	}
+	return r
+}
+
+// Deregister withdraws the endpoint so clients stop picking this instance,
+// then closes the etcd client.
+func (r *serviceRegistration) Deregister(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	if err := r.registry.Deregister(ctx); err != nil {
+		logger.Warnf("Failed to deregister service service=%s id=%s error=%v", r.inst.Name, r.inst.ID, err)
+	} else {
+		logger.Infof("Service deregistered service=%s id=%s", r.inst.Name, r.inst.ID)
+	}
+	_ = r.cli.Close()
+}
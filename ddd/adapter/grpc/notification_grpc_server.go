@@ -2,16 +2,29 @@ package grpc
 
 import (
 	"context"
-	"errors"
-	"fmt"
 	"os"
+	"strconv"
+	"time"
 
-	notificationpb "github.com/jiangqiao2/go-video-proto/proto/notification/notification"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"notification-service/ddd/application/app"
 	"notification-service/ddd/application/cqe"
 	"notification-service/pkg/errno"
+	"notification-service/pkg/grpcutil"
 	"notification-service/pkg/logger"
+	notificationpb "notification-service/proto/notification"
+)
+
+// The created identity is also sent in response header metadata for
+// clients built before CreateNotificationResponse had id and created_at.
+const (
+	// NotificationIDMetadataKey is the response header carrying the created notification ID.
+	NotificationIDMetadataKey = "x-notification-id"
+	// CreatedAtMetadataKey is the response header carrying the creation time (RFC3339Nano, UTC).
+	CreatedAtMetadataKey = "x-notification-created-at"
 )
 
 // NotificationGrpcServer implements the gRPC NotificationService.
@@ -28,20 +41,18 @@ func NewNotificationGrpcServer(notificationApp app.NotificationApp) *Notificatio
 }
 
 // CreateNotification accepts a gRPC request and delegates to the application layer.
+// Failures are reported as gRPC status errors (see grpcutil.StatusError) so
+// clients can distinguish invalid input from transient failures and apply
+// retry policies. Success/Message are still populated on success, and the
+// generated getters report Success=false on a nil response for old clients.
 func (s *NotificationGrpcServer) CreateNotification(ctx context.Context, req *notificationpb.CreateNotificationRequest) (*notificationpb.CreateNotificationResponse, error) {
 	if s.app == nil {
 		logger.WithContext(ctx).Errorf("notification app not initialised for gRPC server")
-		return &notificationpb.CreateNotificationResponse{
-			Success: false,
-			Message: "service unavailable",
-		}, nil
+		return nil, grpcutil.StatusError(errno.ErrServiceUnavailable)
 	}
 
 	if req == nil {
-		return &notificationpb.CreateNotificationResponse{
-			Success: false,
-			Message: "request is nil",
-		}, nil
+		return nil, grpcutil.StatusError(errno.NewSimpleBizError(errno.ErrParameterInvalid, nil, "request"))
 	}
 
 	hostname, err := os.Hostname()
@@ -62,30 +73,25 @@ func (s *NotificationGrpcServer) CreateNotification(ctx context.Context, req *no
 	}
 
 	if !createReq.Validate() {
-		return &notificationpb.CreateNotificationResponse{
-			Success: false,
-			Message: errno.ErrParameterInvalid.Message,
-		}, nil
+		return nil, grpcutil.StatusError(errno.ErrParameterInvalid)
 	}
 
-	if err := s.app.Create(ctx, createReq); err != nil {
+	created, err := s.app.Create(ctx, createReq)
+	if err != nil {
 		logger.WithContext(ctx).Errorf("CreateNotification failed user_uuid=%s type=%s title=%s error=%v",
 			createReq.UserUUID, createReq.Type, createReq.Title, err)
-		var bizErr errno.BizError
-		if errors.As(err, &bizErr) {
-			return &notificationpb.CreateNotificationResponse{
-				Success: false,
-				Message: bizErr.Message(),
-			}, nil
-		}
-		return &notificationpb.CreateNotificationResponse{
-			Success: false,
-			Message: fmt.Sprintf("failed to create notification: %v", err),
-		}, nil
+		return nil, grpcutil.StatusError(err)
 	}
 
+	_ = grpc.SetHeader(ctx, metadata.Pairs(
+		NotificationIDMetadataKey, strconv.FormatUint(created.ID, 10),
+		CreatedAtMetadataKey, created.CreatedAt.UTC().Format(time.RFC3339Nano),
+	))
+
 	return &notificationpb.CreateNotificationResponse{
-		Success: true,
-		Message: "ok",
+		Success:   true,
+		Message:   "ok",
+		Id:        created.ID,
+		CreatedAt: timestamppb.New(created.CreatedAt),
 	}, nil
 }
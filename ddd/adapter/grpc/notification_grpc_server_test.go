@@ -0,0 +1,69 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+
+	"notification-service/ddd/application/cqe"
+	"notification-service/ddd/application/dto"
+	notificationpb "notification-service/proto/notification"
+)
+
+type fakeNotificationApp struct{}
+
+func (fakeNotificationApp) ListNotifications(context.Context, string, *cqe.ListNotificationsReq) (*dto.ListNotificationsResponse, error) {
+	return nil, nil
+}
+
+func (fakeNotificationApp) MarkRead(context.Context, string, *cqe.MarkReadReq) error { return nil }
+
+func (fakeNotificationApp) Create(context.Context, *cqe.CreateNotificationReq) (*dto.CreateNotificationResponse, error) {
+	return &dto.CreateNotificationResponse{ID: 42, CreatedAt: time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)}, nil
+}
+
+func TestCreateNotificationReturnsIDAndCreatedAt(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	notificationpb.RegisterNotificationServiceServer(srv, NewNotificationGrpcServer(fakeNotificationApp{}))
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	var header metadata.MD
+	resp, err := notificationpb.NewNotificationServiceClient(conn).CreateNotification(context.Background(), &notificationpb.CreateNotificationRequest{
+		UserUuid: "u-1",
+		Type:     "comment",
+		Title:    "New comment",
+		Content:  "nice",
+	}, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("CreateNotification: %v", err)
+	}
+
+	want := time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)
+	if !resp.GetSuccess() || resp.GetId() != 42 || !resp.GetCreatedAt().AsTime().Equal(want) {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	// Older clients still read the identity from header metadata.
+	if got := header.Get(NotificationIDMetadataKey); len(got) != 1 || got[0] != "42" {
+		t.Fatalf("%s = %v", NotificationIDMetadataKey, got)
+	}
+	if got := header.Get(CreatedAtMetadataKey); len(got) != 1 || got[0] != want.Format(time.RFC3339Nano) {
+		t.Fatalf("%s = %v", CreatedAtMetadataKey, got)
+	}
+}
@@ -1,8 +1,12 @@
 package http
 
 import (
-	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"math/rand/v2"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -10,11 +14,17 @@ import (
 
 	"notification-service/ddd/application/app"
 	"notification-service/ddd/application/cqe"
+	"notification-service/pkg/config"
 	"notification-service/pkg/errno"
 	"notification-service/pkg/logger"
 	"notification-service/pkg/manager"
+	"notification-service/pkg/metrics"
+	"notification-service/pkg/middleware"
+	"notification-service/pkg/producerauth"
+	"notification-service/pkg/ratelimit"
 	"notification-service/pkg/restapi"
 	"notification-service/pkg/sse"
+	"notification-service/pkg/streamtoken"
 )
 
 var (
@@ -31,9 +41,29 @@ func (p *NotificationControllerPlugin) Name() string {
 
 func (p *NotificationControllerPlugin) MustCreateController() manager.Controller {
 	notificationControllerOnce.Do(func() {
-		singletonNotificationCtrl = &notificationControllerImpl{
-			app: app.DefaultNotificationApp(),
+		stream := config.SSEStreamConfig{HeartbeatInterval: 25 * time.Second}
+		token := config.SSETokenConfig{TTL: 5 * time.Minute, RefreshBefore: time.Minute}
+		var opsCfg config.OpsConfig
+		// 未加载配置时（本地调试）按开发环境处理。
+		dev := true
+		if global := config.GetGlobalConfig(); global != nil {
+			stream = global.SSE.Stream
+			token = global.SSE.Token
+			opsCfg = global.Ops
+			dev = global.Server.IsDev()
 		}
+		ctrl := &notificationControllerImpl{
+			app:           app.DefaultNotificationApp(),
+			stream:        stream,
+			tokens:        mustCreateStreamTokenIssuer(token, dev),
+			refreshBefore: token.RefreshBefore,
+			ops:           app.DefaultOpsApp(),
+			opsAuth:       mustCreateOpsAuth(opsCfg),
+		}
+		if global := config.GetGlobalConfig(); global != nil && global.OpenAPI.Enabled {
+			ctrl.openAuth = middleware.JWTAuthMiddleware(mustCreateJWTVerifier(global.OpenAPI.JWT))
+		}
+		singletonNotificationCtrl = ctrl
 	})
 	return singletonNotificationCtrl
 }
@@ -45,15 +75,55 @@ type NotificationController interface {
 	MarkRead(ctx *gin.Context)
 	Create(ctx *gin.Context)
 	Stream(ctx *gin.Context)
+	StreamToken(ctx *gin.Context)
+	OpsUserNotifications(ctx *gin.Context)
+	OpsUserStats(ctx *gin.Context)
+	OpsMarkRead(ctx *gin.Context)
+	OpsDelete(ctx *gin.Context)
+	OpsResend(ctx *gin.Context)
+	DebugHub(ctx *gin.Context)
+	DebugBridge(ctx *gin.Context)
+	DebugInjectEvent(ctx *gin.Context)
 }
 
 type notificationControllerImpl struct {
 	manager.Controller
-	app app.NotificationApp
+	app    app.NotificationApp
+	stream config.SSEStreamConfig
+	// tokens authorise stream connections; see stream_token.go.
+	tokens        *streamtoken.Issuer
+	refreshBefore time.Duration
+	// openAuth authenticates Open API requests; nil keeps the group empty.
+	openAuth gin.HandlerFunc
+	ops      app.OpsApp
+	// opsAuth checks the ops credential; see notification_ops.go.
+	opsAuth gin.HandlerFunc
 }
 
-// RegisterOpenApi 暂无开放通知接口。
-func (c *notificationControllerImpl) RegisterOpenApi(group *gin.RouterGroup) {}
+// producerAuth 在开启生产方鉴权时校验创建通知的调用方。
+func (c *notificationControllerImpl) producerAuth() gin.HandlerFunc {
+	if reg := producerauth.DefaultRegistry(); reg != nil {
+		return middleware.ProducerAuthMiddleware(reg)
+	}
+	return func(*gin.Context) {}
+}
+
+// RegisterOpenApi 注册浏览器直连的开放接口（open_api.enabled 时）。
+// 与 inner 接口共用 NotificationApp，用户来自 JWT 而非 X-User-UUID；
+// 流接口无法携带 Authorization 头，凭 JWT 换取的流令牌访问。
+func (c *notificationControllerImpl) RegisterOpenApi(group *gin.RouterGroup) {
+	if c.openAuth == nil {
+		return
+	}
+	v1 := group.Group("notification/v1/open")
+	{
+		authed := v1.Group("", c.openAuth)
+		authed.GET("/notifications", c.List)
+		authed.POST("/notifications/read", c.MarkRead)
+		authed.POST("/notifications/stream/token", c.StreamToken)
+		v1.GET("/notifications/stream", c.Stream)
+	}
+}
 
 // RegisterInnerApi 注册内部通知接口（Kong 网关 inner 路由访问）。
 func (c *notificationControllerImpl) RegisterInnerApi(group *gin.RouterGroup) {
@@ -61,20 +131,42 @@ func (c *notificationControllerImpl) RegisterInnerApi(group *gin.RouterGroup) {
 	{
 		v1.GET("/notifications", c.List)
 		v1.POST("/notifications/read", c.MarkRead)
-		v1.POST("/notifications", c.Create)
+		v1.POST("/notifications", c.producerAuth(), c.Create)
+		v1.POST("/notifications/stream/token", c.StreamToken)
 		v1.GET("/notifications/stream", c.Stream)
 	}
 }
 
-func (c *notificationControllerImpl) RegisterDebugApi(group *gin.RouterGroup) {}
-func (c *notificationControllerImpl) RegisterOpsApi(group *gin.RouterGroup)   {}
+// RegisterDebugApi 注册排障接口（Hub/bridge 状态、测试事件与 pprof），
+// 与运维接口共用运维凭证。
+func (c *notificationControllerImpl) RegisterDebugApi(group *gin.RouterGroup) {
+	v1 := group.Group("notification/v1", c.opsAuth)
+	{
+		v1.GET("/hub", c.DebugHub)
+		v1.GET("/bridge", c.DebugBridge)
+		v1.POST("/users/:user_uuid/events", c.DebugInjectEvent)
+	}
+	registerPprof(group.Group("pprof", c.opsAuth))
+}
+
+// RegisterOpsApi 注册运维接口，使用独立的运维凭证；写操作需填写原因并记录审计。
+func (c *notificationControllerImpl) RegisterOpsApi(group *gin.RouterGroup) {
+	v1 := group.Group("notification/v1", c.opsAuth)
+	{
+		v1.GET("/users/:user_uuid/notifications", c.OpsUserNotifications)
+		v1.GET("/users/:user_uuid/stats", c.OpsUserStats)
+		v1.POST("/notifications/:id/read", c.OpsMarkRead)
+		v1.POST("/notifications/:id/delete", c.OpsDelete)
+		v1.POST("/notifications/:id/resend", c.OpsResend)
+	}
+}
 
 func (c *notificationControllerImpl) extractUserUUID(ctx *gin.Context) (string, error) {
-	userUUID := ctx.GetHeader("X-User-UUID")
-	if userUUID == "" {
-		// Fallback for SSE where headers are hard to set; user_uuid can be passed via query.
-		userUUID = ctx.Query("user_uuid")
+	// Open API requests carry the user authenticated from the JWT.
+	if v, ok := ctx.Get(middleware.AuthUserUUIDKey); ok {
+		return v.(string), nil
 	}
+	userUUID := ctx.GetHeader("X-User-UUID")
 	if userUUID == "" {
 		// 通知服务自身不做鉴权，只校验参数是否完整。
 		return "", errno.ErrParameterInvalid
@@ -132,24 +224,63 @@ func (c *notificationControllerImpl) Create(ctx *gin.Context) {
 		restapi.Failed(ctx, errno.ErrParameterInvalid)
 		return
 	}
-	if err := c.app.Create(ctx.Request.Context(), &req); err != nil {
+	resp, err := c.app.Create(ctx.Request.Context(), &req)
+	if err != nil {
+		if errno.AssertBizError(err).Code() == errno.ErrTooManyRequests.Code {
+			var rejected *ratelimit.RejectedError
+			if errors.As(err, &rejected) && rejected.RetryAfter > 0 {
+				ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(rejected.RetryAfter.Seconds()))))
+			}
+			restapi.FailedWithStatus(ctx, err, http.StatusTooManyRequests)
+			return
+		}
 		restapi.Failed(ctx, err)
 		return
 	}
-	restapi.Success(ctx, gin.H{"status": "ok"})
+	restapi.Success(ctx, gin.H{
+		"status":     "ok",
+		"id":         resp.ID,
+		"created_at": resp.CreatedAt,
+	})
 }
 
-// Stream establishes an SSE stream for the current user's notifications.
+// Stream establishes an SSE stream for the user named by the ?token= stream
+// token (see StreamToken).
 // Frontend should listen for "notification.created"/"notification.updated"
-// events and trigger a notifications refresh on each event.
+// events and trigger a notifications refresh on each event, and reconnect
+// with the latest token from "token" events. An "evicted" event means a
+// newer tab of the same user took this stream's slot: the client must close
+// the EventSource and not reconnect until the user returns to the tab.
 func (c *notificationControllerImpl) Stream(ctx *gin.Context) {
-	userUUID, err := c.extractUserUUID(ctx)
+	userUUID, tokenExpiry, err := c.tokens.Verify(ctx.Query("token"))
 	if err != nil {
-		// 缺少 user_uuid 视为参数错误，而不是鉴权失败。
-		restapi.FailedWithStatus(ctx, errno.ErrParameterInvalid, http.StatusBadRequest)
+		logger.WithContext(ctx.Request.Context()).Warnf("notification: SSE stream token rejected error=%v", err)
+		restapi.FailedWithStatus(ctx, errno.ErrUnauthorized, http.StatusUnauthorized)
 		return
 	}
 
+	sub, err := sse.Admit(userUUID, sse.SubscribeOptions{
+		RemoteAddr: ctx.ClientIP(),
+		UserAgent:  ctx.Request.UserAgent(),
+	})
+	if err != nil {
+		var rejected *sse.AdmissionError
+		if !errors.As(err, &rejected) {
+			restapi.FailedWithStatus(ctx, errno.ErrInternalServer, http.StatusInternalServerError)
+			return
+		}
+		logger.WithContext(ctx.Request.Context()).Warnf("notification: SSE stream rejected user_uuid=%s reason=%s", userUUID, rejected.Reason)
+		metrics.ObserveSSERejected(rejected.Reason)
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(rejected.RetryAfter.Seconds()))))
+		if rejected.Reason == sse.AdmitInstanceLimit || rejected.Reason == sse.AdmitDraining {
+			restapi.FailedWithStatus(ctx, errno.ErrServiceUnavailable, http.StatusServiceUnavailable)
+		} else {
+			restapi.FailedWithStatus(ctx, errno.ErrTooManyRequests, http.StatusTooManyRequests)
+		}
+		return
+	}
+	defer sub.Close()
+
 	// Prepare SSE headers.
 	w := ctx.Writer
 	w.Header().Set("Content-Type", "text/event-stream")
@@ -163,49 +294,122 @@ func (c *notificationControllerImpl) Stream(ctx *gin.Context) {
 		return
 	}
 
-	events, unsubscribe := sse.DefaultHub().Subscribe(userUUID)
-	defer unsubscribe()
-
-	// Initial comment to keep some proxies happy.
-	if _, err := w.Write([]byte(": ok\n\n")); err == nil {
+	// Initial comment to keep some proxies happy, with the reconnect delay
+	// the client should use if the stream breaks.
+	hello := ": ok\n"
+	if c.stream.Retry > 0 {
+		hello += "retry: " + strconv.FormatInt(c.stream.Retry.Milliseconds(), 10) + "\n"
+	}
+	if _, err := w.Write([]byte(hello + "\n")); err == nil {
 		flusher.Flush()
 	}
 
 	// Periodic heartbeat to keep long-lived connections from timing out on proxies.
-	heartbeat := time.NewTicker(25 * time.Second)
+	heartbeat := time.NewTicker(c.stream.HeartbeatInterval)
 	defer heartbeat.Stop()
 
+	// Recycle long-lived streams so connections rebalance across instances;
+	// the lifetime is jittered so clients connected together do not all
+	// reconnect together.
+	var expired <-chan time.Time
+	if c.stream.MaxLifetime > 0 {
+		lifetime := time.NewTimer(c.stream.MaxLifetime - time.Duration(rand.Int64N(int64(c.stream.MaxLifetime/10)+1)))
+		defer lifetime.Stop()
+		expired = lifetime.C
+	}
+
+	// Hand out a fresh token before the current one expires, so a client
+	// that reconnects later is still admitted.
+	refresh := time.NewTimer(c.tokenRefreshDelay(tokenExpiry))
+	defer refresh.Stop()
+
 	notify := ctx.Request.Context().Done()
+	var batch []sse.Event
 	for {
 		select {
 		case <-notify:
 			return
-		case <-heartbeat.C:
-			if _, err := w.Write([]byte(": ping\n\n")); err != nil {
-				return
+		case <-sub.Done():
+			var reconnect *sse.ReconnectError
+			if err := sub.Err(); errors.As(err, &reconnect) {
+				// Deliver what was still queued, then send the client to
+				// another instance.
+				batch = append(sub.Drain(batch[:0]), sse.Event{
+					Type:  sse.ReconnectEventType,
+					Data:  gin.H{"reason": "draining"},
+					Retry: reconnect.Retry,
+				})
+				if writeSSEEvents(w, batch) == nil {
+					flusher.Flush()
+				}
+			} else if errors.Is(err, sse.ErrEvicted) {
+				logger.WithContext(ctx.Request.Context()).Infof("notification: SSE stream replaced by a newer connection user_uuid=%s", userUUID)
+				// Tell the client not to reconnect, so tabs over the per-user
+				// cap do not keep evicting each other.
+				batch = append(sub.Drain(batch[:0]), sse.Event{
+					Type:  sse.EvictedEventType,
+					Data:  gin.H{"reason": "replaced"},
+					Retry: sse.EvictedRetry,
+				})
+				if writeSSEEvents(w, batch) == nil {
+					flusher.Flush()
+				}
+			} else if err != nil {
+				logger.WithContext(ctx.Request.Context()).Warnf("notification: SSE stream closed user_uuid=%s error=%v", userUUID, err)
 			}
-			flusher.Flush()
-		case ev, ok := <-events:
-			if !ok {
-				return
+			return
+		case <-expired:
+			// Close ends the subscription before the final drain, so no
+			// event can be queued after it and lost.
+			sub.Close()
+			batch = append(sub.Drain(batch[:0]), sse.Event{
+				Type:  sse.ReconnectEventType,
+				Data:  gin.H{"reason": "max_lifetime"},
+				Retry: c.stream.Retry,
+			})
+			if writeSSEEvents(w, batch) == nil {
+				flusher.Flush()
 			}
-			data, err := json.Marshal(ev.Data)
+			return
+		case <-refresh.C:
+			ev, expiry, err := c.tokenEvent(userUUID)
 			if err != nil {
+				logger.WithContext(ctx.Request.Context()).Errorf("notification: issue stream token failed user_uuid=%s error=%v", userUUID, err)
+				refresh.Reset(c.refreshBefore / 2)
 				continue
 			}
-			if _, err := w.Write([]byte("event: " + ev.Type + "\n")); err != nil {
+			if err := writeSSEEvents(w, []sse.Event{ev}); err != nil {
 				return
 			}
-			if _, err := w.Write([]byte("data: ")); err != nil {
-				return
-			}
-			if _, err := w.Write(data); err != nil {
+			flusher.Flush()
+			refresh.Reset(c.tokenRefreshDelay(expiry))
+		case <-heartbeat.C:
+			if _, err := w.Write([]byte(": ping\n\n")); err != nil {
 				return
 			}
-			if _, err := w.Write([]byte("\n\n")); err != nil {
+			flusher.Flush()
+		case <-sub.Ready():
+			// Write everything queued, then flush once.
+			batch = sub.Drain(batch[:0])
+			if err := writeSSEEvents(w, batch); err != nil {
 				return
 			}
 			flusher.Flush()
 		}
 	}
 }
+
+// writeSSEEvents writes the pre-encoded frames of events; events whose data
+// cannot be encoded are skipped.
+func writeSSEEvents(w io.Writer, events []sse.Event) error {
+	for _, ev := range events {
+		frame, err := ev.Frame()
+		if err != nil {
+			continue
+		}
+		if _, err := w.Write(frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
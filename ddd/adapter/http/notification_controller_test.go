@@ -0,0 +1,63 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"notification-service/ddd/application/cqe"
+	"notification-service/ddd/application/dto"
+)
+
+type fakeNotificationApp struct {
+	created *cqe.CreateNotificationReq
+}
+
+func (a *fakeNotificationApp) ListNotifications(context.Context, string, *cqe.ListNotificationsReq) (*dto.ListNotificationsResponse, error) {
+	return nil, nil
+}
+
+func (a *fakeNotificationApp) MarkRead(context.Context, string, *cqe.MarkReadReq) error { return nil }
+
+func (a *fakeNotificationApp) Create(_ context.Context, req *cqe.CreateNotificationReq) (*dto.CreateNotificationResponse, error) {
+	a.created = req
+	return &dto.CreateNotificationResponse{ID: 42, CreatedAt: time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)}, nil
+}
+
+func TestCreateReturnsIDAndCreatedAt(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	notificationApp := &fakeNotificationApp{}
+	c := &notificationControllerImpl{app: notificationApp}
+	router := gin.New()
+	router.POST("/notifications", c.Create)
+
+	body := `{"user_uuid":"u-1","type":"comment","title":"New comment","content":"nice"}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notifications", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", w.Code, w.Body)
+	}
+
+	var resp struct {
+		Data struct {
+			Status    string    `json:"status"`
+			ID        uint64    `json:"id"`
+			CreatedAt time.Time `json:"created_at"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Data.Status != "ok" || resp.Data.ID != 42 || !resp.Data.CreatedAt.Equal(time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected response %+v", resp.Data)
+	}
+	if notificationApp.created == nil || notificationApp.created.UserUUID != "u-1" {
+		t.Fatalf("request not passed on: %+v", notificationApp.created)
+	}
+}
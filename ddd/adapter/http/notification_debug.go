@@ -0,0 +1,138 @@
+package http
+
+import (
+	"net/http/pprof"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"notification-service/pkg/errno"
+	"notification-service/pkg/logger"
+	"notification-service/pkg/middleware"
+	"notification-service/pkg/restapi"
+	"notification-service/pkg/sse"
+)
+
+const (
+	// debugConnectionLimit Hub 快照默认最多列出的连接数，可用 ?limit= 调整。
+	debugConnectionLimit = 1000
+	// debugEventType 未指定类型时测试事件使用的类型。
+	debugEventType = "debug.test"
+)
+
+// debugEventTypePattern 限定测试事件类型：必须以 debug. 开头且只含 [a-z0-9._-]，
+// 避免伪造业务事件或通过换行注入额外的 SSE 帧。
+var debugEventTypePattern = regexp.MustCompile(`^debug\.[a-z0-9._-]+$`)
+
+// hubSnapshot 本实例 Hub 的排障视图。
+type hubSnapshot struct {
+	Instance    string `json:"instance"`
+	Draining    bool   `json:"draining"`
+	Users       int    `json:"users"`
+	Connections int    `json:"connections"`
+	// Queued 为所有订阅者队列中待发送的事件数，Full 为队列已满的订阅者数。
+	Queued         int                  `json:"queued"`
+	Full           int                  `json:"full"`
+	Stats          sse.HubStats         `json:"stats"`
+	ConnectionList []sse.ConnectionInfo `json:"connection_list"`
+	Truncated      bool                 `json:"truncated"`
+}
+
+// debugEventReq 测试事件请求体。
+type debugEventReq struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// registerPprof 在 group 下暴露 Go profiler，例如：
+// curl -H "Authorization: Bearer <token>" .../debug/pprof/profile?seconds=30 > cpu.pprof
+func registerPprof(group *gin.RouterGroup) {
+	group.GET("/", gin.WrapF(pprof.Index))
+	group.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+	group.GET("/profile", gin.WrapF(pprof.Profile))
+	group.POST("/symbol", gin.WrapF(pprof.Symbol))
+	group.GET("/symbol", gin.WrapF(pprof.Symbol))
+	group.GET("/trace", gin.WrapF(pprof.Trace))
+	for _, name := range []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"} {
+		group.GET("/"+name, gin.WrapH(pprof.Handler(name)))
+	}
+}
+
+// DebugHub 输出本实例 Hub 的连接与投递统计；?user_uuid= 只列出该用户的连接，
+// ?limit= 限制列出的连接数。
+func (c *notificationControllerImpl) DebugHub(ctx *gin.Context) {
+	limit := debugConnectionLimit
+	if v := ctx.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			restapi.Failed(ctx, errno.NewSimpleBizError(errno.ErrParameterInvalid, err, "limit"))
+			return
+		}
+		limit = n
+	}
+
+	hub := sse.DefaultHub()
+	bridge, _ := sse.CurrentBridgeStatus()
+	snap := hubSnapshot{
+		Instance:    bridge.InstanceID,
+		Draining:    sse.Draining(),
+		Users:       len(hub.SubscriberCounts()),
+		Connections: hub.ConnectionCount(),
+		Stats:       hub.Stats(),
+	}
+	var conns []sse.ConnectionInfo
+	if user := ctx.Query("user_uuid"); user != "" {
+		conns = hub.UserConnections(user)
+	} else {
+		conns = hub.Connections()
+	}
+	for _, conn := range conns {
+		snap.Queued += conn.Queued
+		if conn.Capacity > 0 && conn.Queued >= conn.Capacity {
+			snap.Full++
+		}
+	}
+	if len(conns) > limit {
+		conns, snap.Truncated = conns[:limit], true
+	}
+	snap.ConnectionList = conns
+	restapi.Success(ctx, snap)
+}
+
+// DebugBridge 输出跨实例 bridge 的连接状态、最后收到消息的时间与投递延迟。
+func (c *notificationControllerImpl) DebugBridge(ctx *gin.Context) {
+	status, attached := sse.CurrentBridgeStatus()
+	restapi.Success(ctx, gin.H{
+		"attached": attached,
+		"status":   status,
+	})
+}
+
+// DebugInjectEvent 向用户推送一条测试事件，经由 bridge 到达用户所在的实例；
+// 类型须为 debug. 前缀且只含小写字母、数字和 ._-。
+func (c *notificationControllerImpl) DebugInjectEvent(ctx *gin.Context) {
+	var req debugEventReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		restapi.Failed(ctx, errno.NewSimpleBizError(errno.ErrParameterInvalid, err, "body"))
+		return
+	}
+	if req.Type == "" {
+		req.Type = debugEventType
+	}
+	if !debugEventTypePattern.MatchString(req.Type) {
+		restapi.Failed(ctx, errno.NewSimpleBizError(errno.ErrParameterInvalid, nil, "type"))
+		return
+	}
+	userUUID := ctx.Param("user_uuid")
+	if err := sse.DeliverNotification(ctx.Request.Context(), userUUID, sse.Event{Type: req.Type, Data: req.Data}); err != nil {
+		restapi.Failed(ctx, errno.NewBizError(errno.ErrServiceUnavailable, err))
+		return
+	}
+	logger.WithContext(ctx.Request.Context()).Infof("debug api: injected event operator=%s user_uuid=%s type=%s",
+		ctx.GetString(middleware.OpsOperatorKey), userUUID, req.Type)
+	restapi.Success(ctx, gin.H{
+		"status":            "ok",
+		"local_connections": sse.DefaultHub().UserConnectionCount(userUUID),
+	})
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"notification-service/ddd/application/cqe"
+	"notification-service/pkg/config"
+	"notification-service/pkg/errno"
+	"notification-service/pkg/logger"
+	"notification-service/pkg/middleware"
+	"notification-service/pkg/restapi"
+)
+
+// mustCreateOpsAuth builds the ops credential check from the configured
+// operators; without operators every ops request is rejected.
+func mustCreateOpsAuth(cfg config.OpsConfig) gin.HandlerFunc {
+	operators := make(map[string]string, len(cfg.Operators))
+	for _, op := range cfg.Operators {
+		if op.Name == "" || op.Token == "" {
+			logger.Fatal("Invalid ops config: operator name and token are required")
+		}
+		if _, dup := operators[op.Token]; dup {
+			logger.Fatal("Invalid ops config: operators share a token name=" + op.Name)
+		}
+		operators[op.Token] = op.Name
+	}
+	if len(operators) == 0 {
+		logger.Warnf("Ops operators are not configured; ops API requests are rejected")
+	}
+	return middleware.OpsAuthMiddleware(operators)
+}
+
+// OpsUserNotifications 查询用户的全部通知（含已删除）、各 sink 的投递记录与最近的运维操作。
+func (c *notificationControllerImpl) OpsUserNotifications(ctx *gin.Context) {
+	var req cqe.ListNotificationsReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		restapi.Failed(ctx, errno.NewSimpleBizError(errno.ErrParameterInvalid, err, "query"))
+		return
+	}
+	resp, err := c.ops.UserNotifications(ctx.Request.Context(), ctx.Param("user_uuid"), &req)
+	if err != nil {
+		restapi.Failed(ctx, err)
+		return
+	}
+	restapi.Success(ctx, resp)
+}
+
+// OpsUserStats 按通知类型统计用户的通知、未读与已删除数量。
+func (c *notificationControllerImpl) OpsUserStats(ctx *gin.Context) {
+	resp, err := c.ops.UserStats(ctx.Request.Context(), ctx.Param("user_uuid"))
+	if err != nil {
+		restapi.Failed(ctx, err)
+		return
+	}
+	restapi.Success(ctx, resp)
+}
+
+// OpsMarkRead 强制标记通知为已读，请求体需填写 reason。
+func (c *notificationControllerImpl) OpsMarkRead(ctx *gin.Context) {
+	c.opsAction(ctx, c.ops.MarkRead)
+}
+
+// OpsDelete 删除通知，请求体需填写 reason。
+func (c *notificationControllerImpl) OpsDelete(ctx *gin.Context) {
+	c.opsAction(ctx, c.ops.Delete)
+}
+
+// OpsResend 重新推送通知的 SSE 事件，请求体需填写 reason。
+func (c *notificationControllerImpl) OpsResend(ctx *gin.Context) {
+	c.opsAction(ctx, c.ops.Resend)
+}
+
+func (c *notificationControllerImpl) opsAction(ctx *gin.Context, fn func(ctx context.Context, req *cqe.OpsActionReq) error) {
+	var req cqe.OpsActionReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		restapi.Failed(ctx, errno.NewSimpleBizError(errno.ErrParameterInvalid, err, "body"))
+		return
+	}
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		restapi.Failed(ctx, errno.NewSimpleBizError(errno.ErrParameterInvalid, err, "id"))
+		return
+	}
+	req.NotificationID = id
+	req.Operator = ctx.GetString(middleware.OpsOperatorKey)
+	if err := fn(ctx.Request.Context(), &req); err != nil {
+		restapi.Failed(ctx, err)
+		return
+	}
+	logger.WithContext(ctx.Request.Context()).Infof("ops api: %s operator=%s notification_id=%d reason=%q",
+		ctx.FullPath(), req.Operator, req.NotificationID, req.Reason)
+	restapi.Success(ctx, gin.H{"status": "ok"})
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"notification-service/pkg/config"
+	"notification-service/pkg/errno"
+	"notification-service/pkg/jwtauth"
+	"notification-service/pkg/logger"
+	"notification-service/pkg/restapi"
+	"notification-service/pkg/sse"
+	"notification-service/pkg/streamtoken"
+)
+
+// streamTokenEventType carries a refreshed stream token to the client.
+const streamTokenEventType = "token"
+
+// mustCreateStreamTokenIssuer builds the stream token issuer. Without
+// configured keys startup fails unless dev allows a random per-process key,
+// which only works when clients reconnect to the instance that issued their
+// token.
+func mustCreateStreamTokenIssuer(cfg config.SSETokenConfig, dev bool) *streamtoken.Issuer {
+	keys := make([]streamtoken.Key, 0, len(cfg.Keys))
+	for _, k := range cfg.Keys {
+		keys = append(keys, streamtoken.Key{ID: k.ID, Secret: []byte(k.Secret)})
+	}
+	if len(keys) == 0 {
+		if !dev {
+			logger.Fatal("SSE stream token keys are not configured; set sse.token.keys")
+		}
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			logger.Fatal(fmt.Sprintf("Failed to generate stream token key error=%v", err))
+		}
+		keys = append(keys, streamtoken.Key{ID: "local", Secret: secret})
+		logger.Warnf("SSE stream token keys are not configured; tokens are only valid on the issuing instance")
+	}
+	issuer, err := streamtoken.New(keys, cfg.TTL)
+	if err != nil {
+		logger.Fatal(fmt.Sprintf("Invalid SSE stream token config error=%v", err))
+	}
+	return issuer
+}
+
+// mustCreateJWTVerifier builds the Open API JWT verifier.
+func mustCreateJWTVerifier(cfg config.JWTConfig) *jwtauth.Verifier {
+	keys := make([]jwtauth.StaticKey, 0, len(cfg.Keys))
+	for _, k := range cfg.Keys {
+		keys = append(keys, jwtauth.StaticKey{ID: k.ID, Secret: k.Secret, PublicKey: k.PublicKey})
+	}
+	v, err := jwtauth.NewVerifier(jwtauth.Config{
+		Issuer:     cfg.Issuer,
+		Audience:   cfg.Audience,
+		UserClaim:  cfg.UserClaim,
+		Algorithms: cfg.Algorithms,
+		Leeway:     cfg.Leeway,
+		Keys:       keys,
+		JWKSFile:   cfg.JWKSFile,
+	})
+	if err != nil {
+		logger.Fatal(fmt.Sprintf("Invalid open api jwt config error=%v", err))
+	}
+	return v
+}
+
+// StreamToken 为 X-User-UUID 签发短期流令牌，前端以 ?token= 打开 SSE 流。
+func (c *notificationControllerImpl) StreamToken(ctx *gin.Context) {
+	userUUID, err := c.extractUserUUID(ctx)
+	if err != nil {
+		restapi.Failed(ctx, err)
+		return
+	}
+	token, expiresAt, err := c.tokens.Issue(userUUID)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("notification: issue stream token failed user_uuid=%s error=%v", userUUID, err)
+		restapi.FailedWithStatus(ctx, errno.ErrInternalServer, http.StatusInternalServerError)
+		return
+	}
+	restapi.Success(ctx, gin.H{
+		"token":      token,
+		"expires_at": expiresAt,
+	})
+}
+
+// tokenEvent issues a token for userUUID wrapped in a stream event.
+func (c *notificationControllerImpl) tokenEvent(userUUID string) (sse.Event, time.Time, error) {
+	token, expiresAt, err := c.tokens.Issue(userUUID)
+	if err != nil {
+		return sse.Event{}, time.Time{}, err
+	}
+	return sse.Event{
+		Type: streamTokenEventType,
+		Data: gin.H{"token": token, "expires_at": expiresAt},
+	}, expiresAt, nil
+}
+
+// tokenRefreshDelay returns when to send a new token for one expiring at
+// expiry.
+func (c *notificationControllerImpl) tokenRefreshDelay(expiry time.Time) time.Duration {
+	return max(time.Until(expiry)-c.refreshBefore, 0)
+}
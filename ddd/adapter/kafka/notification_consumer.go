@@ -0,0 +1,346 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"notification-service/ddd/application/app"
+	"notification-service/ddd/application/cqe"
+	"notification-service/pkg/config"
+	"notification-service/pkg/errno"
+	"notification-service/pkg/grpcutil"
+	"notification-service/pkg/kafkautil"
+	"notification-service/pkg/logger"
+)
+
+const (
+	// eventTypeHeader lets producers declare the event type without a JSON field.
+	eventTypeHeader = "event_type"
+
+	dlqReasonHeader    = "x-dlq-reason"
+	dlqTopicHeader     = "x-original-topic"
+	dlqPartitionHeader = "x-original-partition"
+	dlqOffsetHeader    = "x-original-offset"
+)
+
+// eventEnvelope is the common shape of go-video domain events.
+type eventEnvelope struct {
+	EventID   string                 `json:"event_id"`
+	EventType string                 `json:"event_type"`
+	Payload   map[string]interface{} `json:"payload"`
+}
+
+// permanentError marks a message that will never succeed (bad payload,
+// missing fields, rejected by validation) and goes straight to the DLQ.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+type route struct {
+	topic            string
+	eventType        string
+	notificationType string
+	recipientField   string
+	title            *template.Template
+	content          *template.Template
+}
+
+// NotificationConsumer maps domain events from other go-video services to
+// NotificationApp.Create calls. Offsets are committed only after a message
+// has been handled or dead-lettered, giving at-least-once processing; the
+// event_id makes a redelivered event create its notification only once.
+type NotificationConsumer struct {
+	app    app.NotificationApp
+	reader kafkautil.Reader
+	dlq    kafkautil.Writer
+	cfg    config.KafkaConsumerConfig
+	routes []route
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// Topics returns the distinct topics referenced by the configured routes.
+func Topics(cfg config.KafkaConsumerConfig) []string {
+	seen := map[string]struct{}{}
+	var topics []string
+	for _, r := range cfg.Routes {
+		if _, ok := seen[r.Topic]; ok || r.Topic == "" {
+			continue
+		}
+		seen[r.Topic] = struct{}{}
+		topics = append(topics, r.Topic)
+	}
+	return topics
+}
+
+// NewNotificationConsumer validates routes and builds a consumer. dlq may be
+// nil, in which case poison messages are logged and skipped.
+func NewNotificationConsumer(notificationApp app.NotificationApp, reader kafkautil.Reader, dlq kafkautil.Writer, cfg config.KafkaConsumerConfig) (*NotificationConsumer, error) {
+	routes := make([]route, 0, len(cfg.Routes))
+	for i, rc := range cfg.Routes {
+		if rc.Topic == "" || rc.NotificationType == "" || rc.RecipientField == "" {
+			return nil, fmt.Errorf("kafka route #%d: topic, notification_type and recipient_field are required", i)
+		}
+		title, err := template.New("title").Option("missingkey=error").Parse(rc.Title)
+		if err != nil {
+			return nil, fmt.Errorf("kafka route #%d: parse title: %w", i, err)
+		}
+		content, err := template.New("content").Option("missingkey=error").Parse(rc.Content)
+		if err != nil {
+			return nil, fmt.Errorf("kafka route #%d: parse content: %w", i, err)
+		}
+		routes = append(routes, route{
+			topic:            rc.Topic,
+			eventType:        rc.EventType,
+			notificationType: rc.NotificationType,
+			recipientField:   rc.RecipientField,
+			title:            title,
+			content:          content,
+		})
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = time.Second
+	}
+	if cfg.MaxRetryBackoff < cfg.RetryBackoff {
+		cfg.MaxRetryBackoff = cfg.RetryBackoff
+	}
+	return &NotificationConsumer{
+		app:    notificationApp,
+		reader: reader,
+		dlq:    dlq,
+		cfg:    cfg,
+		routes: routes,
+		done:   make(chan struct{}),
+	}, nil
+}
+
+// Start runs the consume loop in the background.
+func (c *NotificationConsumer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	go c.run(ctx)
+	logger.Infof("kafka: notification consumer started routes=%d dlq=%s", len(c.routes), c.cfg.DeadLetterTopic)
+}
+
+// Stop cancels the consume loop, waits for the in-flight message and closes
+// the reader and DLQ writer. Uncommitted messages are redelivered later.
+func (c *NotificationConsumer) Stop() {
+	c.once.Do(func() {
+		if c.cancel != nil {
+			c.cancel()
+			<-c.done
+		}
+		if err := c.reader.Close(); err != nil {
+			logger.Errorf("kafka: close reader failed error=%v", err)
+		}
+		if c.dlq != nil {
+			if err := c.dlq.Close(); err != nil {
+				logger.Errorf("kafka: close dlq writer failed error=%v", err)
+			}
+		}
+	})
+}
+
+func (c *NotificationConsumer) run(ctx context.Context) {
+	defer close(c.done)
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Errorf("kafka: fetch message failed error=%v", err)
+			if !sleepCtx(ctx, c.cfg.RetryBackoff) {
+				return
+			}
+			continue
+		}
+		if !c.process(ctx, msg) {
+			return
+		}
+		if err := c.reader.CommitMessages(ctx, msg); err != nil && ctx.Err() == nil {
+			logger.Errorf("kafka: commit offset failed topic=%s partition=%d offset=%d error=%v",
+				msg.Topic, msg.Partition, msg.Offset, err)
+		}
+	}
+}
+
+// process handles one message. Permanent failures are dead-lettered;
+// transient ones (e.g. the database is down) are retried with capped
+// exponential backoff for as long as it takes, so an outage delays events
+// instead of dead-lettering them. It returns false only when ctx was
+// cancelled before the message was settled, in which case the offset must
+// not be committed.
+func (c *NotificationConsumer) process(ctx context.Context, msg kafka.Message) bool {
+	backoff := c.cfg.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := c.handle(ctx, msg)
+		if err == nil {
+			return true
+		}
+		var perm permanentError
+		if errors.As(err, &perm) {
+			if ctx.Err() != nil {
+				return false
+			}
+			return c.deadLetter(ctx, msg, err)
+		}
+		logger.Warnf("kafka: handle message failed, retrying topic=%s partition=%d offset=%d attempt=%d backoff=%s error=%v",
+			msg.Topic, msg.Partition, msg.Offset, attempt, backoff, err)
+		if !sleepCtx(ctx, backoff) {
+			return false
+		}
+		backoff = min(2*backoff, c.cfg.MaxRetryBackoff)
+	}
+}
+
+func (c *NotificationConsumer) handle(ctx context.Context, msg kafka.Message) error {
+	var env eventEnvelope
+	if err := json.Unmarshal(msg.Value, &env); err != nil {
+		return permanentError{fmt.Errorf("decode event: %w", err)}
+	}
+	if env.EventType == "" {
+		env.EventType = kafkautil.Header(msg, eventTypeHeader)
+	}
+
+	r, ok := c.match(msg.Topic, env.EventType)
+	if !ok {
+		// Events we don't turn into notifications are simply acknowledged.
+		return nil
+	}
+
+	req, err := r.build(&env)
+	if err != nil {
+		return permanentError{err}
+	}
+
+	ctx, _ = grpcutil.ContextWithRequestID(ctx, env.EventID)
+	if _, err := c.app.Create(ctx, req); err != nil {
+		// A redelivered event was already turned into a notification.
+		if errno.AssertBizError(err).Code() == errno.ErrConflict.Code {
+			logger.WithContext(ctx).Infof("kafka: duplicate event skipped topic=%s event_id=%s", msg.Topic, env.EventID)
+			return nil
+		}
+		// Rate-limited events would be rejected again on retry.
+		if bizErr := errno.AssertBizError(err); bizErr.Code() == errno.ErrParameterInvalid.Code || bizErr.Code() == errno.ErrTooManyRequests.Code || errors.Is(err, errno.ErrParameterInvalid) {
+			return permanentError{err}
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *NotificationConsumer) match(topic, eventType string) (*route, bool) {
+	for i := range c.routes {
+		r := &c.routes[i]
+		if r.topic == topic && (r.eventType == "" || r.eventType == eventType) {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// build renders the notification for an event matched by this route.
+func (r *route) build(env *eventEnvelope) (*cqe.CreateNotificationReq, error) {
+	recipient, _ := env.Payload[r.recipientField].(string)
+	if recipient == "" {
+		return nil, fmt.Errorf("event %s: missing recipient field %q", env.EventType, r.recipientField)
+	}
+	title, err := render(r.title, env.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("event %s: render title: %w", env.EventType, err)
+	}
+	content, err := render(r.content, env.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("event %s: render content: %w", env.EventType, err)
+	}
+	extra, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("event %s: encode extra: %w", env.EventType, err)
+	}
+	req := &cqe.CreateNotificationReq{
+		UserUUID:  recipient,
+		Type:      r.notificationType,
+		Title:     title,
+		Content:   content,
+		ExtraJSON: string(extra),
+		EventID:   env.EventID,
+	}
+	if !req.Validate() {
+		return nil, fmt.Errorf("event %s: rendered notification is incomplete", env.EventType)
+	}
+	return req, nil
+}
+
+func render(tpl *template.Template, data map[string]interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// deadLetter forwards a poison message to the DLQ topic with the failure
+// reason in headers. It returns false if the DLQ write was interrupted by
+// shutdown so the original offset stays uncommitted.
+func (c *NotificationConsumer) deadLetter(ctx context.Context, msg kafka.Message, cause error) bool {
+	logger.Errorf("kafka: dead-lettering message topic=%s partition=%d offset=%d error=%v",
+		msg.Topic, msg.Partition, msg.Offset, cause)
+	if c.dlq == nil || c.cfg.DeadLetterTopic == "" {
+		return true
+	}
+
+	reason := ""
+	if cause != nil {
+		reason = cause.Error()
+	}
+	headers := append([]kafka.Header{}, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: dlqReasonHeader, Value: []byte(reason)},
+		kafka.Header{Key: dlqTopicHeader, Value: []byte(msg.Topic)},
+		kafka.Header{Key: dlqPartitionHeader, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: dlqOffsetHeader, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+	)
+	for {
+		err := c.dlq.WriteMessages(ctx, kafka.Message{
+			Topic:   c.cfg.DeadLetterTopic,
+			Key:     msg.Key,
+			Value:   msg.Value,
+			Headers: headers,
+		})
+		if err == nil {
+			return true
+		}
+		logger.Errorf("kafka: write dlq message failed topic=%s error=%v", c.cfg.DeadLetterTopic, err)
+		if !sleepCtx(ctx, c.cfg.RetryBackoff) {
+			return false
+		}
+	}
+}
+
+// sleepCtx waits for d or until ctx is done; it reports whether the full
+// duration elapsed.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,320 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"notification-service/ddd/application/cqe"
+	"notification-service/ddd/application/dto"
+	"notification-service/pkg/config"
+	"notification-service/pkg/errno"
+	"notification-service/pkg/kafkautil"
+)
+
+// memBroker is a local stand-in for a Kafka broker: a single-partition log
+// per topic with a committed offset for one consumer group.
+type memBroker struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	logs      map[string][]kafka.Message
+	committed map[string]int64
+	fetched   map[string]int64
+	closed    bool
+}
+
+func newMemBroker() *memBroker {
+	b := &memBroker{
+		logs:      map[string][]kafka.Message{},
+		committed: map[string]int64{},
+		fetched:   map[string]int64{},
+	}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+func (b *memBroker) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, m := range msgs {
+		m.Offset = int64(len(b.logs[m.Topic]))
+		b.logs[m.Topic] = append(b.logs[m.Topic], m)
+	}
+	b.cond.Broadcast()
+	return nil
+}
+
+// reader returns a group reader over topics, resuming from committed offsets.
+func (b *memBroker) reader(topics ...string) *memReader {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = false
+	for _, t := range topics {
+		b.fetched[t] = b.committed[t]
+	}
+	return &memReader{broker: b, topics: topics}
+}
+
+func (b *memBroker) Close() error { return nil }
+
+func (b *memBroker) messages(topic string) []kafka.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]kafka.Message(nil), b.logs[topic]...)
+}
+
+type memReader struct {
+	broker *memBroker
+	topics []string
+}
+
+func (r *memReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	b := r.broker
+	stop := context.AfterFunc(ctx, func() {
+		b.mu.Lock()
+		b.cond.Broadcast()
+		b.mu.Unlock()
+	})
+	defer stop()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for {
+		if err := ctx.Err(); err != nil {
+			return kafka.Message{}, err
+		}
+		if b.closed {
+			return kafka.Message{}, errors.New("reader closed")
+		}
+		for _, t := range r.topics {
+			if off := b.fetched[t]; off < int64(len(b.logs[t])) {
+				b.fetched[t] = off + 1
+				return b.logs[t][off], nil
+			}
+		}
+		b.cond.Wait()
+	}
+}
+
+func (r *memReader) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	b := r.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, m := range msgs {
+		if m.Offset+1 > b.committed[m.Topic] {
+			b.committed[m.Topic] = m.Offset + 1
+		}
+	}
+	b.cond.Broadcast()
+	return nil
+}
+
+func (r *memReader) Close() error {
+	b := r.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.cond.Broadcast()
+	return nil
+}
+
+type fakeApp struct {
+	mu       sync.Mutex
+	created  []*cqe.CreateNotificationReq
+	failures int
+}
+
+func (a *fakeApp) ListNotifications(context.Context, string, *cqe.ListNotificationsReq) (*dto.ListNotificationsResponse, error) {
+	return nil, nil
+}
+
+func (a *fakeApp) MarkRead(context.Context, string, *cqe.MarkReadReq) error { return nil }
+
+func (a *fakeApp) Create(_ context.Context, req *cqe.CreateNotificationReq) (*dto.CreateNotificationResponse, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.failures > 0 {
+		a.failures--
+		return nil, errno.NewBizError(errno.ErrDatabase, errors.New("db down"))
+	}
+	for _, c := range a.created {
+		if req.EventID != "" && c.EventID == req.EventID {
+			return nil, errno.NewSimpleBizError(errno.ErrConflict, nil, "event "+req.EventID)
+		}
+	}
+	a.created = append(a.created, req)
+	return &dto.CreateNotificationResponse{ID: uint64(len(a.created))}, nil
+}
+
+func (a *fakeApp) createdCount() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.created)
+}
+
+func testConsumerConfig() config.KafkaConsumerConfig {
+	return config.KafkaConsumerConfig{
+		DeadLetterTopic: "notification.dlq",
+		RetryBackoff:    time.Millisecond,
+		MaxRetryBackoff: 2 * time.Millisecond,
+		Routes: []config.KafkaRouteConfig{{
+			Topic:            "comment.events",
+			EventType:        "comment.created",
+			NotificationType: "comment",
+			RecipientField:   "video_owner_uuid",
+			Title:            "New comment",
+			Content:          "{{.commenter_name}}: {{.content}}",
+		}},
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestNotificationConsumerRoutesAndDeadLetters(t *testing.T) {
+	broker := newMemBroker()
+	notificationApp := &fakeApp{failures: 1}
+	c, err := NewNotificationConsumer(notificationApp, broker.reader("comment.events"), broker, testConsumerConfig())
+	if err != nil {
+		t.Fatalf("NewNotificationConsumer: %v", err)
+	}
+
+	_ = broker.WriteMessages(context.Background(),
+		kafka.Message{Topic: "comment.events", Value: []byte(`{"event_id":"e1","event_type":"comment.created","payload":{"video_owner_uuid":"u-1","commenter_name":"bob","content":"nice"}}`)},
+		kafka.Message{Topic: "comment.events", Value: []byte(`not json`)},
+		kafka.Message{Topic: "comment.events", Value: []byte(`{"event_id":"e2","event_type":"comment.deleted","payload":{}}`)},
+		kafka.Message{Topic: "comment.events", Value: []byte(`{"event_id":"e3","event_type":"comment.created","payload":{"commenter_name":"bob","content":"x"}}`)},
+	)
+
+	c.Start()
+	waitFor(t, func() bool { return len(broker.messages("notification.dlq")) == 2 })
+	c.Stop()
+
+	if got := notificationApp.createdCount(); got != 1 {
+		t.Fatalf("created = %d, want 1", got)
+	}
+	req := notificationApp.created[0]
+	if req.UserUUID != "u-1" || req.Type != "comment" || req.Content != "bob: nice" {
+		t.Fatalf("unexpected notification: %+v", req)
+	}
+	if off := broker.committed["comment.events"]; off != 4 {
+		t.Fatalf("committed offset = %d, want 4", off)
+	}
+	dlq := broker.messages("notification.dlq")
+	if reason := kafkautil.Header(dlq[0], dlqReasonHeader); reason == "" {
+		t.Fatalf("dlq message missing reason header")
+	}
+	if topic := kafkautil.Header(dlq[1], dlqTopicHeader); topic != "comment.events" {
+		t.Fatalf("dlq original topic = %q", topic)
+	}
+}
+
+func TestNotificationConsumerSkipsRedeliveredEvent(t *testing.T) {
+	broker := newMemBroker()
+	notificationApp := &fakeApp{}
+	c, err := NewNotificationConsumer(notificationApp, broker.reader("comment.events"), broker, testConsumerConfig())
+	if err != nil {
+		t.Fatalf("NewNotificationConsumer: %v", err)
+	}
+
+	event := []byte(`{"event_id":"e1","event_type":"comment.created","payload":{"video_owner_uuid":"u-1","commenter_name":"bob","content":"nice"}}`)
+	_ = broker.WriteMessages(context.Background(),
+		kafka.Message{Topic: "comment.events", Value: event},
+		kafka.Message{Topic: "comment.events", Value: event},
+	)
+
+	c.Start()
+	waitFor(t, func() bool {
+		broker.mu.Lock()
+		defer broker.mu.Unlock()
+		return broker.committed["comment.events"] == 2
+	})
+	c.Stop()
+
+	if got := notificationApp.createdCount(); got != 1 {
+		t.Fatalf("created = %d, want 1", got)
+	}
+	if notificationApp.created[0].EventID != "e1" {
+		t.Fatalf("event id not passed on: %+v", notificationApp.created[0])
+	}
+	if dlq := broker.messages("notification.dlq"); len(dlq) != 0 {
+		t.Fatalf("duplicate event dead-lettered: %d messages", len(dlq))
+	}
+}
+
+func TestNotificationConsumerRetriesTransientErrorsWithoutDeadLettering(t *testing.T) {
+	broker := newMemBroker()
+	// Far more failures than any fixed attempt budget, as during an outage.
+	notificationApp := &fakeApp{failures: 50}
+	c, err := NewNotificationConsumer(notificationApp, broker.reader("comment.events"), broker, testConsumerConfig())
+	if err != nil {
+		t.Fatalf("NewNotificationConsumer: %v", err)
+	}
+
+	_ = broker.WriteMessages(context.Background(),
+		kafka.Message{Topic: "comment.events", Value: []byte(`{"event_id":"e1","event_type":"comment.created","payload":{"video_owner_uuid":"u-1","commenter_name":"a","content":"b"}}`)},
+	)
+	c.Start()
+	waitFor(t, func() bool { return notificationApp.createdCount() == 1 })
+	c.Stop()
+
+	if dlq := broker.messages("notification.dlq"); len(dlq) != 0 {
+		t.Fatalf("transient failure dead-lettered: %d messages", len(dlq))
+	}
+	if off := broker.committed["comment.events"]; off != 1 {
+		t.Fatalf("committed offset = %d, want 1", off)
+	}
+}
+
+func TestNotificationConsumerLeavesOffsetUncommittedOnShutdown(t *testing.T) {
+	broker := newMemBroker()
+	notificationApp := &fakeApp{failures: 1000}
+	cfg := testConsumerConfig()
+	cfg.RetryBackoff = time.Hour
+	c, err := NewNotificationConsumer(notificationApp, broker.reader("comment.events"), broker, cfg)
+	if err != nil {
+		t.Fatalf("NewNotificationConsumer: %v", err)
+	}
+
+	_ = broker.WriteMessages(context.Background(),
+		kafka.Message{Topic: "comment.events", Value: []byte(`{"event_type":"comment.created","payload":{"video_owner_uuid":"u-1","commenter_name":"a","content":"b"}}`)},
+	)
+	c.Start()
+	waitFor(t, func() bool {
+		notificationApp.mu.Lock()
+		defer notificationApp.mu.Unlock()
+		return notificationApp.failures < 1000
+	})
+	c.Stop()
+
+	if off := broker.committed["comment.events"]; off != 0 {
+		t.Fatalf("committed offset = %d, want 0 so the message is redelivered", off)
+	}
+
+	// A restarted consumer picks the message up again.
+	notificationApp.failures = 0
+	c2, _ := NewNotificationConsumer(notificationApp, broker.reader("comment.events"), broker, testConsumerConfig())
+	c2.Start()
+	waitFor(t, func() bool { return notificationApp.createdCount() == 1 })
+	c2.Stop()
+}
+
+func TestNewNotificationConsumerRejectsInvalidRoutes(t *testing.T) {
+	cfg := testConsumerConfig()
+	cfg.Routes[0].RecipientField = ""
+	if _, err := NewNotificationConsumer(&fakeApp{}, newMemBroker().reader(), nil, cfg); err == nil {
+		t.Fatalf("expected error for route without recipient_field")
+	}
+}
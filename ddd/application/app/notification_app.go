@@ -2,35 +2,55 @@ package app
 
 import (
 	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 
 	"notification-service/ddd/application/cqe"
 	"notification-service/ddd/application/dto"
 	"notification-service/ddd/domain/entity"
+	"notification-service/ddd/domain/event"
 	drepo "notification-service/ddd/domain/repo"
 	"notification-service/ddd/infrastructure/database/persistence"
 	"notification-service/pkg/errno"
-	"notification-service/pkg/sse"
+	"notification-service/pkg/grpcutil"
+	"notification-service/pkg/logger"
+	"notification-service/pkg/metrics"
+	"notification-service/pkg/producerauth"
+	"notification-service/pkg/ratelimit"
+	"notification-service/pkg/tracing"
 )
 
 // NotificationApp 应用服务接口，编排通知相关用例。
 type NotificationApp interface {
 	ListNotifications(ctx context.Context, userUUID string, req *cqe.ListNotificationsReq) (*dto.ListNotificationsResponse, error)
 	MarkRead(ctx context.Context, userUUID string, req *cqe.MarkReadReq) error
-	Create(ctx context.Context, req *cqe.CreateNotificationReq) error
+	Create(ctx context.Context, req *cqe.CreateNotificationReq) (*dto.CreateNotificationResponse, error)
 }
 
 type notificationAppImpl struct {
-	repo drepo.NotificationRepository
+	repo  drepo.NotificationRepository
+	tx    drepo.TransactionManager
+	relay *OutboxRelay
+	// limiter 为 nil 时不限频。
+	limiter *ratelimit.Limiter
 }
 
 // DefaultNotificationApp 返回默认的应用服务实现。
 func DefaultNotificationApp() NotificationApp {
 	return &notificationAppImpl{
-		repo: persistence.NewNotificationRepository(),
+		repo:    persistence.NewNotificationRepository(),
+		tx:      persistence.NewTransactionManager(),
+		relay:   DefaultOutboxRelay(),
+		limiter: ratelimit.Default(),
 	}
 }
 
-func (a *notificationAppImpl) ListNotifications(ctx context.Context, userUUID string, req *cqe.ListNotificationsReq) (*dto.ListNotificationsResponse, error) {
+func (a *notificationAppImpl) ListNotifications(ctx context.Context, userUUID string, req *cqe.ListNotificationsReq) (_ *dto.ListNotificationsResponse, err error) {
+	ctx, span := tracing.Start(ctx, "NotificationApp.ListNotifications", attribute.String("user_uuid", userUUID))
+	defer func() { tracing.End(span, err) }()
+
 	if userUUID == "" {
 		return nil, errno.ErrUnauthorized
 	}
@@ -66,32 +86,65 @@ func (a *notificationAppImpl) ListNotifications(ctx context.Context, userUUID st
 	}, nil
 }
 
-func (a *notificationAppImpl) MarkRead(ctx context.Context, userUUID string, req *cqe.MarkReadReq) error {
+func (a *notificationAppImpl) MarkRead(ctx context.Context, userUUID string, req *cqe.MarkReadReq) (err error) {
+	ctx, span := tracing.Start(ctx, "NotificationApp.MarkRead", attribute.String("user_uuid", userUUID))
+	defer func() { tracing.End(span, err) }()
+
 	if userUUID == "" {
 		return errno.ErrUnauthorized
 	}
 	if !req.Validate() {
 		return errno.ErrParameterInvalid
 	}
-	if err := a.repo.MarkRead(ctx, userUUID, req.IDs); err != nil {
-		return err
-	}
-	// After marking as read, push updated unread count to SSE subscribers.
-	if unread, err := a.repo.CountUnread(ctx, userUUID); err == nil {
-		sse.PublishNotification(userUUID, sse.Event{
-			Type: "notification.updated",
-			Data: map[string]interface{}{
-				"unread_count": unread,
-			},
-		})
+	// The read event carries the updated unread count, which the SSE sink
+	// pushes to subscribers as "notification.updated". Only notifications
+	// this call actually marked read are published; IDs that were already
+	// read or belong to another user produce no event.
+	err = a.tx.Transaction(ctx, func(ctx context.Context) error {
+		changed, err := a.repo.MarkRead(ctx, userUUID, req.IDs)
+		if err != nil || len(changed) == 0 {
+			return err
+		}
+		unread, err := a.repo.CountUnread(ctx, userUUID)
+		if err != nil {
+			return err
+		}
+		ev := a.newEvent(ctx, event.TypeNotificationRead, userUUID, changed)
+		ev.UnreadCount = unread
+		return a.relay.Enqueue(ctx, changed[0], ev)
+	})
+	if err != nil {
+		return errno.NewBizError(errno.ErrDatabase, err)
 	}
+	a.relay.Notify()
 	return nil
 }
 
-// Create 创建一条新的通知记录（内部调用）。
-func (a *notificationAppImpl) Create(ctx context.Context, req *cqe.CreateNotificationReq) error {
+// Create 创建一条新的通知记录（内部调用），返回新通知的 ID 与创建时间。
+// 通知与 notification.created 生命周期事件在同一事务中写入 outbox，由
+// OutboxRelay 负责投递，保证 Redis/Kafka 故障或进程退出时事件不会丢失。
+// 超出频控时按规则拒绝、静默丢弃（返回 ID 0）或只落库不实时推送。
+func (a *notificationAppImpl) Create(ctx context.Context, req *cqe.CreateNotificationReq) (_ *dto.CreateNotificationResponse, err error) {
+	ctx, span := tracing.Start(ctx, "NotificationApp.Create")
+	defer func() { tracing.End(span, err) }()
+
 	if req == nil || !req.Validate() {
-		return errno.ErrParameterInvalid
+		return nil, errno.ErrParameterInvalid
+	}
+	span.SetAttributes(attribute.String("user_uuid", req.UserUUID), attribute.String("notification.type", req.Type))
+	// 经过生产方鉴权的请求只能发送登记过的通知类型；内部调用（如 Kafka 消费）不受限。
+	if p, ok := producerauth.FromContext(ctx); ok && !p.Allows(req.Type) {
+		return nil, errno.NewSimpleBizError(errno.ErrForbidden, nil, "notification type "+req.Type+" for producer "+p.Name)
+	}
+	limit := a.checkRateLimit(ctx, req)
+	if limit.Action != ratelimit.Allow {
+		span.SetAttributes(attribute.String("ratelimit.rule", limit.Rule), attribute.String("ratelimit.action", limit.Action.String()))
+	}
+	switch limit.Action {
+	case ratelimit.Reject:
+		return nil, errno.NewSimpleBizError(errno.ErrTooManyRequests, &ratelimit.RejectedError{Rule: limit.Rule, RetryAfter: limit.RetryAfter})
+	case ratelimit.Drop:
+		return &dto.CreateNotificationResponse{CreatedAt: time.Now()}, nil
 	}
 	n := entity.NewNotification(
 		req.UserUUID,
@@ -100,19 +153,81 @@ func (a *notificationAppImpl) Create(ctx context.Context, req *cqe.CreateNotific
 		req.Content,
 		req.ExtraJSON,
 	)
-	if err := a.repo.Create(ctx, n); err != nil {
-		return err
-	}
-	// On new notification creation, emit an SSE event so frontends can refresh.
-	if req.UserUUID != "" {
-		if unread, err := a.repo.CountUnread(ctx, req.UserUUID); err == nil {
-			sse.PublishNotification(req.UserUUID, sse.Event{
-				Type: "notification.created",
-				Data: map[string]interface{}{
-					"unread_count": unread,
-				},
-			})
+	n.EventID = req.EventID
+	err = a.tx.Transaction(ctx, func(ctx context.Context) error {
+		if err := a.repo.Create(ctx, n); err != nil {
+			return err
+		}
+		unread, err := a.repo.CountUnread(ctx, n.UserUUID)
+		if err != nil {
+			return err
 		}
+		ev := a.newEvent(ctx, event.TypeNotificationCreated, n.UserUUID, []uint64{n.ID})
+		ev.NotificationType = n.Type
+		ev.UnreadCount = unread
+		ev.NoPush = limit.Action == ratelimit.NoPush
+		return a.relay.Enqueue(ctx, n.ID, ev)
+	})
+	if err != nil {
+		// 未创建的通知不占用频控配额。
+		a.refundRateLimit(ctx, limit)
 	}
-	return nil
+	if errors.Is(err, drepo.ErrDuplicateEvent) {
+		return nil, errno.NewSimpleBizError(errno.ErrConflict, err, "event "+req.EventID)
+	}
+	if err != nil {
+		return nil, errno.NewBizError(errno.ErrDatabase, err)
+	}
+	a.relay.Notify()
+	producer := ""
+	if p, ok := producerauth.FromContext(ctx); ok {
+		producer = p.Name
+	}
+	metrics.ObserveNotificationCreated(n.Type, producer)
+
+	return &dto.CreateNotificationResponse{
+		ID:        n.ID,
+		CreatedAt: n.CreatedAt,
+	}, nil
+}
+
+// checkRateLimit 按生产方、接收人和通知类型检查频控。Redis 不可用时放行，
+// 避免频控故障阻断通知创建。
+func (a *notificationAppImpl) checkRateLimit(ctx context.Context, req *cqe.CreateNotificationReq) ratelimit.Decision {
+	if a.limiter == nil {
+		return ratelimit.Decision{}
+	}
+	s := ratelimit.Subject{Recipient: req.UserUUID, Type: req.Type}
+	if p, ok := producerauth.FromContext(ctx); ok {
+		s.Producer = p.Name
+	}
+	d, err := a.limiter.Allow(ctx, s)
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Rate limit check failed, allowing notification error=%v", err)
+		return ratelimit.Decision{}
+	}
+	if d.Action != ratelimit.Allow {
+		metrics.ObserveRateLimited(d.Rule, d.Action.String())
+		logger.WithContext(ctx).Infof("Notification rate limited rule=%s action=%s producer=%s user_uuid=%s type=%s",
+			d.Rule, d.Action, s.Producer, s.Recipient, s.Type)
+	}
+	return d
+}
+
+// refundRateLimit 退还 checkRateLimit 计入的配额。
+func (a *notificationAppImpl) refundRateLimit(ctx context.Context, d ratelimit.Decision) {
+	if a.limiter == nil {
+		return
+	}
+	if err := a.limiter.Refund(context.WithoutCancel(ctx), d); err != nil {
+		logger.WithContext(ctx).Warnf("Rate limit refund failed rule=%s error=%v", d.Rule, err)
+	}
+}
+
+// newEvent 构造生命周期事件，并带上 request_id 与 trace context 便于跨系统追踪。
+func (a *notificationAppImpl) newEvent(ctx context.Context, eventType, userUUID string, ids []uint64) *event.NotificationEvent {
+	ev := event.NewNotificationEvent(eventType, userUUID, ids)
+	ev.RequestID = grpcutil.RequestIDFromContext(ctx)
+	ev.TraceContext = tracing.Inject(ctx)
+	return ev
 }
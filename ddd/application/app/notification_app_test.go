@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"notification-service/ddd/application/cqe"
+	"notification-service/ddd/domain/entity"
+	"notification-service/ddd/domain/event"
+	"notification-service/pkg/config"
+)
+
+func TestMarkReadPublishesOnlyChangedIDs(t *testing.T) {
+	outbox := &fakeOutboxRepo{events: map[uint64]*entity.OutboxEvent{}}
+	repo := &fakeNotificationRepo{byID: map[uint64]*entity.Notification{
+		1: {ID: 1, UserUUID: "u1", Type: "like"},
+		2: {ID: 2, UserUUID: "u1", Type: "like", IsRead: true},
+		3: {ID: 3, UserUUID: "u2", Type: "like"},
+	}}
+	relay := NewOutboxRelay(fakeTx{}, outbox, config.OutboxConfig{}, &flakySink{name: sseSinkName}, &flakySink{name: "kafka"})
+	a := &notificationAppImpl{repo: repo, tx: fakeTx{}, relay: relay}
+	ctx := context.Background()
+
+	if err := a.MarkRead(ctx, "u1", &cqe.MarkReadReq{IDs: []uint64{3, 2, 1}}); err != nil {
+		t.Fatalf("MarkRead: %v", err)
+	}
+	if len(outbox.events) != 2 {
+		t.Fatalf("expected 2 outbox events, got %d", len(outbox.events))
+	}
+	for _, ev := range outbox.events {
+		var payload event.NotificationEvent
+		if err := json.Unmarshal([]byte(ev.Payload), &payload); err != nil {
+			t.Fatalf("decode payload: %v", err)
+		}
+		if ev.AggregateID != 1 || !slices.Equal(payload.NotificationIDs, []uint64{1}) {
+			t.Fatalf("unexpected event aggregate=%d ids=%v", ev.AggregateID, payload.NotificationIDs)
+		}
+	}
+	if repo.byID[3].IsRead {
+		t.Fatalf("another user's notification was marked read")
+	}
+
+	// Nothing left to mark: no event is published.
+	if err := a.MarkRead(ctx, "u1", &cqe.MarkReadReq{IDs: []uint64{1, 2}}); err != nil {
+		t.Fatalf("MarkRead again: %v", err)
+	}
+	if len(outbox.events) != 2 {
+		t.Fatalf("expected no new events, got %d", len(outbox.events))
+	}
+}
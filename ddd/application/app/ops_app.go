@@ -0,0 +1,247 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"notification-service/ddd/application/cqe"
+	"notification-service/ddd/application/dto"
+	"notification-service/ddd/domain/entity"
+	"notification-service/ddd/domain/event"
+	drepo "notification-service/ddd/domain/repo"
+	"notification-service/ddd/infrastructure/database/persistence"
+	"notification-service/pkg/errno"
+	"notification-service/pkg/grpcutil"
+	"notification-service/pkg/tracing"
+)
+
+// opsAuditLimit 查询用户通知时附带的最近审计记录条数。
+const opsAuditLimit = 50
+
+// OpsApp 运维用例：排查用户为何没有收到通知，以及强制已读、删除、重发 SSE
+// 事件。写操作与审计记录、生命周期事件在同一事务中提交。
+type OpsApp interface {
+	UserNotifications(ctx context.Context, userUUID string, req *cqe.ListNotificationsReq) (*dto.OpsUserNotificationsResponse, error)
+	UserStats(ctx context.Context, userUUID string) (*dto.OpsUserStatsResponse, error)
+	MarkRead(ctx context.Context, req *cqe.OpsActionReq) error
+	Delete(ctx context.Context, req *cqe.OpsActionReq) error
+	Resend(ctx context.Context, req *cqe.OpsActionReq) error
+}
+
+type opsAppImpl struct {
+	repo   drepo.NotificationRepository
+	outbox drepo.OutboxRepository
+	audit  drepo.OpsAuditRepository
+	tx     drepo.TransactionManager
+	relay  *OutboxRelay
+}
+
+// DefaultOpsApp 返回默认的运维应用服务实现。
+func DefaultOpsApp() OpsApp {
+	return &opsAppImpl{
+		repo:   persistence.NewNotificationRepository(),
+		outbox: persistence.NewOutboxRepository(),
+		audit:  persistence.NewOpsAuditRepository(),
+		tx:     persistence.NewTransactionManager(),
+		relay:  DefaultOutboxRelay(),
+	}
+}
+
+func (a *opsAppImpl) UserNotifications(ctx context.Context, userUUID string, req *cqe.ListNotificationsReq) (*dto.OpsUserNotificationsResponse, error) {
+	if userUUID == "" {
+		return nil, errno.NewSimpleBizError(errno.ErrParameterInvalid, nil, "user_uuid")
+	}
+	req.Normalize()
+	list, err := a.repo.ListAllByUser(ctx, userUUID, (req.Page-1)*req.PageSize, req.PageSize)
+	if err != nil {
+		return nil, errno.NewBizError(errno.ErrDatabase, err)
+	}
+	unread, err := a.repo.CountUnread(ctx, userUUID)
+	if err != nil {
+		return nil, errno.NewBizError(errno.ErrDatabase, err)
+	}
+
+	ids := make([]uint64, 0, len(list))
+	for _, n := range list {
+		ids = append(ids, n.ID)
+	}
+	events, err := a.outbox.ListByAggregates(ctx, ids)
+	if err != nil {
+		return nil, errno.NewBizError(errno.ErrDatabase, err)
+	}
+	deliveries := make(map[uint64][]dto.OutboxDeliveryDto, len(ids))
+	for _, ev := range events {
+		deliveries[ev.AggregateID] = append(deliveries[ev.AggregateID], dto.OutboxDeliveryDto{
+			Sink:          ev.Sink,
+			EventType:     ev.EventType,
+			Status:        outboxStatusName(ev.Status),
+			Attempts:      ev.Attempts,
+			LastError:     ev.LastError,
+			CreatedAt:     ev.CreatedAt,
+			NextAttemptAt: ev.NextAttemptAt,
+			SentAt:        ev.SentAt,
+		})
+	}
+
+	audits, err := a.audit.ListByUser(ctx, userUUID, opsAuditLimit)
+	if err != nil {
+		return nil, errno.NewBizError(errno.ErrDatabase, err)
+	}
+
+	resp := &dto.OpsUserNotificationsResponse{
+		Notifications: make([]dto.OpsNotificationDto, 0, len(list)),
+		UnreadCount:   unread,
+		Audit:         make([]dto.OpsAuditDto, 0, len(audits)),
+	}
+	for _, n := range list {
+		d := deliveries[n.ID]
+		if d == nil {
+			d = []dto.OutboxDeliveryDto{}
+		}
+		resp.Notifications = append(resp.Notifications, dto.OpsNotificationDto{
+			NotificationDto: dto.NotificationDto{
+				ID:        n.ID,
+				Type:      n.Type,
+				Title:     n.Title,
+				Content:   n.Content,
+				ExtraJSON: n.ExtraJSON,
+				IsRead:    n.IsRead,
+				CreatedAt: n.CreatedAt,
+				ReadAt:    n.ReadAt,
+			},
+			UserUUID:   n.UserUUID,
+			DeletedAt:  n.DeletedAt,
+			Deliveries: d,
+		})
+	}
+	for _, au := range audits {
+		resp.Audit = append(resp.Audit, dto.OpsAuditDto{
+			Operator:       au.Operator,
+			Action:         au.Action,
+			NotificationID: au.NotificationID,
+			Reason:         au.Reason,
+			CreatedAt:      au.CreatedAt,
+		})
+	}
+	return resp, nil
+}
+
+func (a *opsAppImpl) UserStats(ctx context.Context, userUUID string) (*dto.OpsUserStatsResponse, error) {
+	if userUUID == "" {
+		return nil, errno.NewSimpleBizError(errno.ErrParameterInvalid, nil, "user_uuid")
+	}
+	counts, err := a.repo.CountByType(ctx, userUUID)
+	if err != nil {
+		return nil, errno.NewBizError(errno.ErrDatabase, err)
+	}
+	resp := &dto.OpsUserStatsResponse{UserUUID: userUUID, ByType: make([]dto.TypeCountDto, 0, len(counts))}
+	for _, c := range counts {
+		resp.Total += c.Total
+		resp.Unread += c.Unread
+		resp.Deleted += c.Deleted
+		resp.ByType = append(resp.ByType, dto.TypeCountDto{Type: c.Type, Total: c.Total, Unread: c.Unread, Deleted: c.Deleted})
+	}
+	return resp, nil
+}
+
+// MarkRead 强制把通知标记为已读，并推送新的未读数。
+func (a *opsAppImpl) MarkRead(ctx context.Context, req *cqe.OpsActionReq) error {
+	return a.apply(ctx, req, entity.OpsActionMarkRead, func(ctx context.Context, n *entity.Notification) (bool, error) {
+		changed, err := a.repo.MarkRead(ctx, n.UserUUID, []uint64{n.ID})
+		if err != nil || len(changed) == 0 {
+			return false, err
+		}
+		return true, a.enqueue(ctx, event.TypeNotificationRead, n)
+	})
+}
+
+// Delete 软删除通知，并推送新的未读数。
+func (a *opsAppImpl) Delete(ctx context.Context, req *cqe.OpsActionReq) error {
+	return a.apply(ctx, req, entity.OpsActionDelete, func(ctx context.Context, n *entity.Notification) (bool, error) {
+		changed, err := a.repo.SoftDelete(ctx, n.UserUUID, []uint64{n.ID})
+		if err != nil || changed == 0 {
+			return false, err
+		}
+		return true, a.enqueue(ctx, event.TypeNotificationDeleted, n)
+	})
+}
+
+// Resend 重新投递通知的 notification.created SSE 事件；只经过 SSE sink，
+// 不会在 Kafka 生命周期流中产生重复的创建事件。
+func (a *opsAppImpl) Resend(ctx context.Context, req *cqe.OpsActionReq) error {
+	return a.apply(ctx, req, entity.OpsActionResend, func(ctx context.Context, n *entity.Notification) (bool, error) {
+		unread, err := a.repo.CountUnread(ctx, n.UserUUID)
+		if err != nil {
+			return false, err
+		}
+		ev := event.NewNotificationEvent(event.TypeNotificationCreated, n.UserUUID, []uint64{n.ID})
+		ev.RequestID = grpcutil.RequestIDFromContext(ctx)
+		ev.TraceContext = tracing.Inject(ctx)
+		ev.NotificationType = n.Type
+		ev.UnreadCount = unread
+		return true, a.relay.EnqueueTo(ctx, sseSinkName, n.ID, ev)
+	})
+}
+
+// apply 校验请求，在事务中锁定通知、执行 fn，并在 fn 确实修改了通知时写入审计记录。
+// 已删除的通知不能再被修改或重发；已处于目标状态（如已读）时不做任何记录。
+func (a *opsAppImpl) apply(ctx context.Context, req *cqe.OpsActionReq, action string, fn func(ctx context.Context, n *entity.Notification) (bool, error)) error {
+	if !req.Validate() {
+		return errno.NewSimpleBizError(errno.ErrParameterInvalid, nil, "reason")
+	}
+	err := a.tx.Transaction(ctx, func(ctx context.Context) error {
+		n, err := a.repo.GetForUpdate(ctx, req.NotificationID)
+		if err != nil {
+			return err
+		}
+		if n == nil || n.DeletedAt != nil {
+			return errno.ErrNotFound
+		}
+		changed, err := fn(ctx, n)
+		if err != nil || !changed {
+			return err
+		}
+		return a.audit.Create(ctx, &entity.OpsAudit{
+			Operator:       req.Operator,
+			Action:         action,
+			UserUUID:       n.UserUUID,
+			NotificationID: n.ID,
+			Reason:         req.Reason,
+		})
+	})
+	if errors.Is(err, errno.ErrNotFound) {
+		return errno.ErrNotFound
+	}
+	if err != nil {
+		return errno.NewBizError(errno.ErrDatabase, err)
+	}
+	a.relay.Notify()
+	return nil
+}
+
+// enqueue 写入携带最新未读数的生命周期事件。
+func (a *opsAppImpl) enqueue(ctx context.Context, eventType string, n *entity.Notification) error {
+	unread, err := a.repo.CountUnread(ctx, n.UserUUID)
+	if err != nil {
+		return err
+	}
+	ev := event.NewNotificationEvent(eventType, n.UserUUID, []uint64{n.ID})
+	ev.RequestID = grpcutil.RequestIDFromContext(ctx)
+	ev.TraceContext = tracing.Inject(ctx)
+	ev.NotificationType = n.Type
+	ev.UnreadCount = unread
+	return a.relay.Enqueue(ctx, n.ID, ev)
+}
+
+func outboxStatusName(s entity.OutboxStatus) string {
+	switch s {
+	case entity.OutboxStatusPending:
+		return "pending"
+	case entity.OutboxStatusSent:
+		return "sent"
+	case entity.OutboxStatusDead:
+		return "dead"
+	default:
+		return "unknown"
+	}
+}
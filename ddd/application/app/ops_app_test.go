@@ -0,0 +1,163 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"notification-service/ddd/application/cqe"
+	"notification-service/ddd/domain/entity"
+	"notification-service/ddd/domain/event"
+	"notification-service/pkg/config"
+	"notification-service/pkg/errno"
+)
+
+type fakeNotificationRepo struct {
+	byID map[uint64]*entity.Notification
+}
+
+func (r *fakeNotificationRepo) Create(_ context.Context, n *entity.Notification) error {
+	n.ID = uint64(len(r.byID) + 1)
+	r.byID[n.ID] = n
+	return nil
+}
+
+func (r *fakeNotificationRepo) ListByUser(ctx context.Context, userUUID string, offset, limit int) ([]*entity.Notification, error) {
+	return nil, nil
+}
+
+func (r *fakeNotificationRepo) CountUnread(_ context.Context, userUUID string) (int64, error) {
+	var n int64
+	for _, v := range r.byID {
+		if v.UserUUID == userUUID && !v.IsRead && v.DeletedAt == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *fakeNotificationRepo) MarkRead(_ context.Context, userUUID string, ids []uint64) ([]uint64, error) {
+	var changed []uint64
+	for _, id := range ids {
+		if n := r.byID[id]; n != nil && n.UserUUID == userUUID && !n.IsRead && n.DeletedAt == nil {
+			n.IsRead = true
+			changed = append(changed, id)
+		}
+	}
+	return changed, nil
+}
+
+func (r *fakeNotificationRepo) SoftDelete(_ context.Context, userUUID string, ids []uint64) (int64, error) {
+	var changed int64
+	now := time.Now()
+	for _, id := range ids {
+		if n := r.byID[id]; n.DeletedAt == nil {
+			n.DeletedAt = &now
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+func (r *fakeNotificationRepo) Get(_ context.Context, id uint64) (*entity.Notification, error) {
+	return r.byID[id], nil
+}
+
+func (r *fakeNotificationRepo) GetForUpdate(ctx context.Context, id uint64) (*entity.Notification, error) {
+	return r.Get(ctx, id)
+}
+
+func (r *fakeNotificationRepo) ListAllByUser(ctx context.Context, userUUID string, offset, limit int) ([]*entity.Notification, error) {
+	return nil, nil
+}
+
+func (r *fakeNotificationRepo) CountByType(ctx context.Context, userUUID string) ([]entity.TypeCount, error) {
+	return nil, nil
+}
+
+type fakeOpsAuditRepo struct {
+	records []*entity.OpsAudit
+}
+
+func (r *fakeOpsAuditRepo) Create(_ context.Context, a *entity.OpsAudit) error {
+	r.records = append(r.records, a)
+	return nil
+}
+
+func (r *fakeOpsAuditRepo) ListByUser(ctx context.Context, userUUID string, limit int) ([]*entity.OpsAudit, error) {
+	return r.records, nil
+}
+
+func newTestOpsApp() (*opsAppImpl, *fakeOutboxRepo, *fakeOpsAuditRepo) {
+	outbox := &fakeOutboxRepo{events: map[uint64]*entity.OutboxEvent{}}
+	audit := &fakeOpsAuditRepo{}
+	repo := &fakeNotificationRepo{byID: map[uint64]*entity.Notification{
+		1: {ID: 1, UserUUID: "u1", Type: "like"},
+		2: {ID: 2, UserUUID: "u1", Type: "comment"},
+	}}
+	relay := NewOutboxRelay(fakeTx{}, outbox, config.OutboxConfig{}, &flakySink{name: sseSinkName}, &flakySink{name: "kafka"})
+	return &opsAppImpl{repo: repo, outbox: outbox, audit: audit, tx: fakeTx{}, relay: relay}, outbox, audit
+}
+
+func TestOpsDeleteRecordsAuditAndEvent(t *testing.T) {
+	a, outbox, audit := newTestOpsApp()
+	ctx := context.Background()
+
+	req := &cqe.OpsActionReq{Operator: "alice", NotificationID: 1, Reason: "duplicate"}
+	if err := a.Delete(ctx, req); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if len(audit.records) != 1 || audit.records[0].Action != entity.OpsActionDelete || audit.records[0].Operator != "alice" {
+		t.Fatalf("unexpected audit %+v", audit.records)
+	}
+	// One lifecycle event per sink, carrying the unread count without the
+	// deleted notification.
+	if len(outbox.events) != 2 {
+		t.Fatalf("expected 2 outbox events, got %d", len(outbox.events))
+	}
+	for _, ev := range outbox.events {
+		if ev.EventType != event.TypeNotificationDeleted {
+			t.Fatalf("unexpected event type %q", ev.EventType)
+		}
+	}
+
+	// A deleted notification cannot be changed again.
+	if err := a.Resend(ctx, req); err != errno.ErrNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if err := a.MarkRead(ctx, &cqe.OpsActionReq{Operator: "alice", NotificationID: 2, Reason: " "}); err == nil {
+		t.Fatalf("expected a reason to be required")
+	}
+}
+
+func TestOpsMarkReadSkipsUnchangedNotification(t *testing.T) {
+	a, outbox, audit := newTestOpsApp()
+	ctx := context.Background()
+	a.repo.(*fakeNotificationRepo).byID[2].IsRead = true
+
+	if err := a.MarkRead(ctx, &cqe.OpsActionReq{Operator: "alice", NotificationID: 2, Reason: "user report"}); err != nil {
+		t.Fatalf("MarkRead: %v", err)
+	}
+	// Already read: nothing changed, so nothing is audited or published.
+	if len(audit.records) != 0 || len(outbox.events) != 0 {
+		t.Fatalf("unexpected audit %+v or events %d", audit.records, len(outbox.events))
+	}
+}
+
+func TestOpsResendOnlyUsesSSESink(t *testing.T) {
+	a, outbox, audit := newTestOpsApp()
+	if err := a.Resend(context.Background(), &cqe.OpsActionReq{Operator: "bob", NotificationID: 2, Reason: "client missed it"}); err != nil {
+		t.Fatalf("Resend: %v", err)
+	}
+	if len(outbox.events) != 1 {
+		t.Fatalf("expected 1 outbox event, got %d", len(outbox.events))
+	}
+	for _, ev := range outbox.events {
+		if ev.Sink != sseSinkName || ev.EventType != event.TypeNotificationCreated {
+			t.Fatalf("unexpected outbox event %+v", ev)
+		}
+	}
+	if len(audit.records) != 1 || audit.records[0].Action != entity.OpsActionResend {
+		t.Fatalf("unexpected audit %+v", audit.records)
+	}
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+
+	"notification-service/ddd/domain/entity"
+	"notification-service/ddd/domain/event"
+	"notification-service/pkg/kafkautil"
+)
+
+// KafkaOutboxSink 把通知生命周期事件发布到 Kafka，消息以 user_uuid 为 key，
+// 保证同一用户的事件在分区内有序。
+type KafkaOutboxSink struct {
+	writer kafkautil.Writer
+	topic  string
+}
+
+// NewKafkaOutboxSink 创建 Kafka 生命周期事件 sink。
+func NewKafkaOutboxSink(writer kafkautil.Writer, topic string) *KafkaOutboxSink {
+	return &KafkaOutboxSink{writer: writer, topic: topic}
+}
+
+func (s *KafkaOutboxSink) Name() string {
+	return "kafka"
+}
+
+func (s *KafkaOutboxSink) Publish(ctx context.Context, ev *entity.OutboxEvent) error {
+	return s.writer.WriteMessages(ctx, kafka.Message{
+		Topic: s.topic,
+		Key:   []byte(ev.UserUUID),
+		Value: []byte(ev.Payload),
+		Headers: []kafka.Header{
+			{Key: "event_type", Value: []byte(ev.EventType)},
+			{Key: "schema_version", Value: []byte(strconv.Itoa(event.SchemaVersion))},
+		},
+	})
+}
+
+// Close 关闭底层 writer，由 OutboxRelay.Stop 调用。
+func (s *KafkaOutboxSink) Close() error {
+	return s.writer.Close()
+}
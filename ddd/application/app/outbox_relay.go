@@ -0,0 +1,358 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"notification-service/ddd/domain/entity"
+	"notification-service/ddd/domain/event"
+	drepo "notification-service/ddd/domain/repo"
+	"notification-service/ddd/infrastructure/database/persistence"
+	"notification-service/pkg/config"
+	"notification-service/pkg/kafkautil"
+	"notification-service/pkg/logger"
+	"notification-service/pkg/sse"
+	"notification-service/pkg/tracing"
+)
+
+// OutboxSink 是 outbox 事件的投递目标。Enqueue 为每个 sink 单独写一行，
+// 返回错误时只有该 sink 的这一行按退避重试（at-least-once）。
+type OutboxSink interface {
+	Name() string
+	Publish(ctx context.Context, ev *entity.OutboxEvent) error
+}
+
+// OutboxRelay 轮询 outbox 表并把事件投递到各个 sink。
+// 领取在短事务中完成并给事件加租约，投递与结果记录都在事务外逐行进行，
+// 网络 I/O 期间不持有行锁，单行记录失败也不会让已投递的事件重发。
+type OutboxRelay struct {
+	tx     drepo.TransactionManager
+	outbox drepo.OutboxRepository
+	sinks  map[string]OutboxSink
+	names  []string
+	cfg    config.OutboxConfig
+
+	// lastPurge 为上次按保留时长清理的时间，只在 relay 循环中访问。
+	lastPurge time.Time
+
+	wake     chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+var (
+	outboxRelayOnce      sync.Once
+	singletonOutboxRelay *OutboxRelay
+)
+
+// DefaultOutboxRelay 返回进程级 relay：始终投递到 SSE（Redis bridge / 本地 Hub），
+// 开启 kafka.producer 时额外投递到 Kafka 生命周期 topic。
+func DefaultOutboxRelay() *OutboxRelay {
+	outboxRelayOnce.Do(func() {
+		var cfg config.OutboxConfig
+		sinks := []OutboxSink{&sseOutboxSink{}}
+		if global := config.GetGlobalConfig(); global != nil {
+			cfg = global.Outbox
+			if global.Kafka.Enabled && global.Kafka.Producer.Enabled {
+				sinks = append(sinks, NewKafkaOutboxSink(kafkautil.NewWriter(global.Kafka), global.Kafka.Producer.Topic))
+			}
+		}
+		singletonOutboxRelay = NewOutboxRelay(
+			persistence.NewTransactionManager(),
+			persistence.NewOutboxRepository(),
+			cfg,
+			sinks...,
+		)
+	})
+	return singletonOutboxRelay
+}
+
+// NewOutboxRelay 创建 relay；cfg 中未设置的字段使用保守默认值。
+func NewOutboxRelay(tx drepo.TransactionManager, outbox drepo.OutboxRepository, cfg config.OutboxConfig, sinks ...OutboxSink) *OutboxRelay {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = time.Minute
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 5 * time.Minute
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = 7 * 24 * time.Hour
+	}
+	r := &OutboxRelay{
+		tx:     tx,
+		outbox: outbox,
+		sinks:  make(map[string]OutboxSink, len(sinks)),
+		cfg:    cfg,
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	for _, sink := range sinks {
+		r.sinks[sink.Name()] = sink
+		r.names = append(r.names, sink.Name())
+	}
+	return r
+}
+
+// Enqueue 为每个 sink 写入一条 outbox 事件。ctx 应携带业务事务，
+// 使事件与状态变更原子提交；提交后调用 Notify 可加快投递。
+func (r *OutboxRelay) Enqueue(ctx context.Context, aggregateID uint64, ev *event.NotificationEvent) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("encode outbox event: %w", err)
+	}
+	for _, name := range r.names {
+		if err := r.outbox.Create(ctx, entity.NewOutboxEvent(name, aggregateID, ev.UserUUID, ev.EventType, string(payload))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnqueueTo 只为名为 sink 的投递目标写入 outbox 事件，用于重发等只需
+// 单一投递路径的场景；sink 未注册时返回错误。
+func (r *OutboxRelay) EnqueueTo(ctx context.Context, sink string, aggregateID uint64, ev *event.NotificationEvent) error {
+	if _, ok := r.sinks[sink]; !ok {
+		return fmt.Errorf("outbox sink %q not registered", sink)
+	}
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("encode outbox event: %w", err)
+	}
+	return r.outbox.Create(ctx, entity.NewOutboxEvent(sink, aggregateID, ev.UserUUID, ev.EventType, string(payload)))
+}
+
+// Start 在后台启动 relay 循环。
+func (r *OutboxRelay) Start() {
+	go r.run()
+	logger.Infof("outbox: relay started poll_interval=%s batch_size=%d sinks=%v", r.cfg.PollInterval, r.cfg.BatchSize, r.names)
+}
+
+// Stop 停止 relay，等待当前批次处理完成并关闭各个 sink。
+func (r *OutboxRelay) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+		<-r.done
+		for _, name := range r.names {
+			if closer, ok := r.sinks[name].(io.Closer); ok {
+				if err := closer.Close(); err != nil {
+					logger.Errorf("outbox: close sink failed sink=%s error=%v", name, err)
+				}
+			}
+		}
+	})
+}
+
+// Notify 提示 relay 立即处理一次，避免新事件等待完整的轮询周期。
+func (r *OutboxRelay) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *OutboxRelay) run() {
+	defer close(r.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-r.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.drain(ctx)
+		if time.Since(r.lastPurge) >= outboxPurgeInterval {
+			r.purge(ctx)
+			r.lastPurge = time.Now()
+		}
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		case <-r.wake:
+		}
+	}
+}
+
+// drain 连续处理批次，直到没有到期事件或出错。
+func (r *OutboxRelay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := r.relayBatch(ctx)
+		if err != nil {
+			logger.Errorf("outbox: relay batch failed error=%v", err)
+			return
+		}
+		if n == 0 {
+			return
+		}
+	}
+}
+
+const (
+	// outboxPurgeInterval 为两次清理之间的最短间隔。
+	outboxPurgeInterval = 10 * time.Minute
+	// outboxPurgeBatch 为每条 DELETE 删除的最大行数，避免长时间持锁。
+	outboxPurgeBatch = 1000
+)
+
+// purge 分批删除超过保留时长的已投递与死信事件，直到没有可删除的行或出错。
+func (r *OutboxRelay) purge(ctx context.Context) {
+	before := time.Now().Add(-r.cfg.Retention)
+	var total int64
+	for ctx.Err() == nil {
+		n, err := r.outbox.PurgeBefore(ctx, before, outboxPurgeBatch)
+		if err != nil {
+			logger.Errorf("outbox: purge failed deleted=%d error=%v", total, err)
+			return
+		}
+		total += n
+		if n < outboxPurgeBatch {
+			break
+		}
+	}
+	if total > 0 {
+		logger.Infof("outbox: purged events deleted=%d before=%s", total, before.Format(time.RFC3339))
+	}
+}
+
+// relayBatch 在短事务中领取一批事件，事务提交后逐条投递并记录结果。
+// 租约过半后不再投递本批剩余事件，避免租约到期被其他实例重复领取。
+func (r *OutboxRelay) relayBatch(ctx context.Context) (int, error) {
+	now := time.Now()
+	var events []*entity.OutboxEvent
+	err := r.tx.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		events, err = r.outbox.ClaimDue(ctx, now, now.Add(r.cfg.Lease), r.cfg.BatchSize)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	deadline := now.Add(r.cfg.Lease / 2)
+	for i, ev := range events {
+		if ctx.Err() != nil || time.Now().After(deadline) {
+			return i, nil
+		}
+		r.handle(ctx, ev)
+	}
+	return len(events), nil
+}
+
+// handle 投递单条事件并单独记录结果；记录失败只打日志，事件在租约到期后重新投递。
+func (r *OutboxRelay) handle(ctx context.Context, ev *entity.OutboxEvent) {
+	deliverErr := r.deliver(ctx, ev)
+	// 投递已经发生，结果必须落库，不随 relay 停止而取消。
+	ctx = context.WithoutCancel(ctx)
+
+	var err error
+	attempts := ev.Attempts + 1
+	switch {
+	case deliverErr == nil:
+		err = r.outbox.MarkSent(ctx, ev.ID, time.Now())
+	case attempts >= r.cfg.MaxAttempts:
+		logger.Errorf("outbox: event dead-lettered id=%d sink=%s type=%s user_uuid=%s attempts=%d error=%v",
+			ev.ID, ev.Sink, ev.EventType, ev.UserUUID, attempts, deliverErr)
+		err = r.outbox.MarkDead(ctx, ev.ID, attempts, deliverErr.Error())
+	default:
+		next := time.Now().Add(r.backoff(attempts))
+		logger.Warnf("outbox: event delivery failed id=%d sink=%s type=%s attempts=%d next_attempt_at=%s error=%v",
+			ev.ID, ev.Sink, ev.EventType, attempts, next.Format(time.RFC3339), deliverErr)
+		err = r.outbox.MarkRetry(ctx, ev.ID, attempts, next, deliverErr.Error())
+	}
+	if err != nil {
+		logger.Errorf("outbox: record delivery result failed id=%d sink=%s error=%v", ev.ID, ev.Sink, err)
+	}
+}
+
+func (r *OutboxRelay) deliver(ctx context.Context, ev *entity.OutboxEvent) error {
+	sink, ok := r.sinks[ev.Sink]
+	if !ok {
+		// The sink was disabled after the event was written; retries will
+		// succeed once it is configured again, otherwise the row goes dead.
+		return fmt.Errorf("sink %q is not configured", ev.Sink)
+	}
+	return sink.Publish(ctx, ev)
+}
+
+// backoff 指数退避：base * 2^(attempts-1)，不超过 MaxBackoff。
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	d := r.cfg.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= r.cfg.MaxBackoff {
+			return r.cfg.MaxBackoff
+		}
+	}
+	return d
+}
+
+// sseOutboxSink 把生命周期事件转换为前端使用的 SSE 事件，
+// 经由 Redis bridge 或本地 Hub 投递给订阅者。
+type sseOutboxSink struct{}
+
+// sseSinkName 是 SSE sink 在 outbox 表中的名称。
+const sseSinkName = "sse"
+
+func (s *sseOutboxSink) Name() string {
+	return sseSinkName
+}
+
+func (s *sseOutboxSink) Publish(ctx context.Context, ev *entity.OutboxEvent) (err error) {
+	var ne event.NotificationEvent
+	if err := json.Unmarshal([]byte(ev.Payload), &ne); err != nil {
+		return fmt.Errorf("decode outbox payload: %w", err)
+	}
+
+	// Deliver as part of the request that produced the event.
+	ctx, span := tracing.Start(tracing.Extract(ctx, ne.TraceContext), "outbox.deliver sse",
+		attribute.String("notification.event_type", ne.EventType),
+		attribute.Int("outbox.attempts", ev.Attempts))
+	defer func() { tracing.End(span, err) }()
+
+	data := map[string]interface{}{
+		"unread_count": ne.UnreadCount,
+	}
+	sseType := "notification.updated"
+	// A rate-limited notification only refreshes the unread badge.
+	if ne.EventType == event.TypeNotificationCreated && !ne.NoPush {
+		sseType = "notification.created"
+		if len(ne.NotificationIDs) > 0 {
+			data["notification_id"] = ne.NotificationIDs[0]
+		}
+	}
+	return sse.DeliverNotification(ctx, ev.UserUUID, sse.Event{
+		Type: sseType,
+		Data: data,
+		// An update only carries the unread count, so a newer one supersedes
+		// any the client has not received yet.
+		Coalesce: sseType == "notification.updated",
+	})
+}
@@ -0,0 +1,267 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"notification-service/ddd/domain/entity"
+	"notification-service/ddd/domain/event"
+	"notification-service/pkg/config"
+)
+
+type fakeTx struct{}
+
+func (fakeTx) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeOutboxRepo struct {
+	events map[uint64]*entity.OutboxEvent
+	lastID uint64
+}
+
+func (r *fakeOutboxRepo) Create(_ context.Context, ev *entity.OutboxEvent) error {
+	r.lastID++
+	ev.ID = r.lastID
+	if ev.CreatedAt.IsZero() {
+		ev.CreatedAt = time.Now()
+	}
+	r.events[ev.ID] = ev
+	return nil
+}
+
+func (r *fakeOutboxRepo) ClaimDue(_ context.Context, now, leaseUntil time.Time, limit int) ([]*entity.OutboxEvent, error) {
+	ids := make([]uint64, 0, len(r.events))
+	for id := range r.events {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	var res []*entity.OutboxEvent
+	heads := map[string]bool{}
+	for _, id := range ids {
+		ev := r.events[id]
+		if ev.Status != entity.OutboxStatusPending {
+			continue
+		}
+		head := ev.Sink + "/" + ev.UserUUID
+		if heads[head] {
+			continue
+		}
+		heads[head] = true
+		if !ev.NextAttemptAt.After(now) && len(res) < limit {
+			ev.NextAttemptAt = leaseUntil
+			res = append(res, ev)
+		}
+	}
+	return res, nil
+}
+
+func (r *fakeOutboxRepo) MarkSent(_ context.Context, id uint64, sentAt time.Time) error {
+	r.events[id].Status = entity.OutboxStatusSent
+	r.events[id].SentAt = &sentAt
+	return nil
+}
+
+func (r *fakeOutboxRepo) MarkRetry(_ context.Context, id uint64, attempts int, next time.Time, lastErr string) error {
+	ev := r.events[id]
+	ev.Attempts, ev.NextAttemptAt, ev.LastError = attempts, next, lastErr
+	return nil
+}
+
+func (r *fakeOutboxRepo) MarkDead(_ context.Context, id uint64, attempts int, lastErr string) error {
+	ev := r.events[id]
+	ev.Status, ev.Attempts, ev.LastError = entity.OutboxStatusDead, attempts, lastErr
+	return nil
+}
+
+func (r *fakeOutboxRepo) PurgeBefore(_ context.Context, before time.Time, limit int) (int64, error) {
+	var n int64
+	for id, ev := range r.events {
+		if ev.Status != entity.OutboxStatusPending && ev.CreatedAt.Before(before) && n < int64(limit) {
+			delete(r.events, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *fakeOutboxRepo) ListByAggregates(_ context.Context, ids []uint64) ([]*entity.OutboxEvent, error) {
+	var res []*entity.OutboxEvent
+	for _, ev := range r.events {
+		for _, id := range ids {
+			if ev.AggregateID == id {
+				res = append(res, ev)
+			}
+		}
+	}
+	return res, nil
+}
+
+type flakySink struct {
+	name      string
+	failures  int
+	delivered []uint64
+}
+
+func (s *flakySink) Name() string {
+	if s.name == "" {
+		return "flaky"
+	}
+	return s.name
+}
+
+func (s *flakySink) Publish(_ context.Context, ev *entity.OutboxEvent) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("redis down")
+	}
+	s.delivered = append(s.delivered, ev.ID)
+	return nil
+}
+
+func TestOutboxRelayRetriesUntilDelivered(t *testing.T) {
+	repo := &fakeOutboxRepo{events: map[uint64]*entity.OutboxEvent{}}
+	sink := &flakySink{failures: 1}
+	relay := NewOutboxRelay(fakeTx{}, repo, config.OutboxConfig{BaseBackoff: time.Millisecond, MaxAttempts: 3}, sink)
+
+	ev := entity.NewOutboxEvent("flaky", 1, "u-1", "notification.created", `{"unread_count":1}`)
+	_ = repo.Create(context.Background(), ev)
+
+	if _, err := relay.relayBatch(context.Background()); err != nil {
+		t.Fatalf("relayBatch: %v", err)
+	}
+	if ev.Status != entity.OutboxStatusPending || ev.Attempts != 1 || ev.LastError == "" {
+		t.Fatalf("expected scheduled retry, got %+v", ev)
+	}
+
+	ev.NextAttemptAt = time.Now()
+	if _, err := relay.relayBatch(context.Background()); err != nil {
+		t.Fatalf("relayBatch: %v", err)
+	}
+	if ev.Status != entity.OutboxStatusSent || len(sink.delivered) != 1 {
+		t.Fatalf("expected event delivered, got %+v", ev)
+	}
+}
+
+func TestOutboxRelayDeadLettersAfterMaxAttempts(t *testing.T) {
+	repo := &fakeOutboxRepo{events: map[uint64]*entity.OutboxEvent{}}
+	relay := NewOutboxRelay(fakeTx{}, repo, config.OutboxConfig{MaxAttempts: 1}, &flakySink{failures: 10})
+
+	ev := entity.NewOutboxEvent("flaky", 1, "u-1", "notification.created", `{}`)
+	_ = repo.Create(context.Background(), ev)
+
+	if _, err := relay.relayBatch(context.Background()); err != nil {
+		t.Fatalf("relayBatch: %v", err)
+	}
+	if ev.Status != entity.OutboxStatusDead {
+		t.Fatalf("expected dead event, got %+v", ev)
+	}
+}
+
+func TestOutboxRelaySinksRetryIndependently(t *testing.T) {
+	repo := &fakeOutboxRepo{events: map[uint64]*entity.OutboxEvent{}}
+	healthy := &flakySink{name: "sse"}
+	broken := &flakySink{name: "kafka", failures: 10}
+	relay := NewOutboxRelay(fakeTx{}, repo, config.OutboxConfig{MaxAttempts: 5}, healthy, broken)
+
+	ev := event.NewNotificationEvent(event.TypeNotificationCreated, "u-1", []uint64{7})
+	if err := relay.Enqueue(context.Background(), 7, ev); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if len(repo.events) != 2 {
+		t.Fatalf("expected one outbox row per sink, got %d", len(repo.events))
+	}
+
+	if _, err := relay.relayBatch(context.Background()); err != nil {
+		t.Fatalf("relayBatch: %v", err)
+	}
+	for _, row := range repo.events {
+		switch row.Sink {
+		case "sse":
+			if row.Status != entity.OutboxStatusSent {
+				t.Fatalf("sse row should be sent, got %+v", row)
+			}
+		case "kafka":
+			if row.Status != entity.OutboxStatusPending || row.Attempts != 1 {
+				t.Fatalf("kafka row should be pending retry, got %+v", row)
+			}
+		}
+	}
+	if len(healthy.delivered) != 1 {
+		t.Fatalf("healthy sink delivered %d events, want 1", len(healthy.delivered))
+	}
+}
+
+func TestOutboxRelayKeepsPerUserOrder(t *testing.T) {
+	repo := &fakeOutboxRepo{events: map[uint64]*entity.OutboxEvent{}}
+	sink := &flakySink{failures: 1}
+	relay := NewOutboxRelay(fakeTx{}, repo, config.OutboxConfig{BaseBackoff: time.Hour, MaxAttempts: 3}, sink)
+
+	first := entity.NewOutboxEvent("flaky", 1, "u-1", "notification.created", `{"unread_count":1}`)
+	second := entity.NewOutboxEvent("flaky", 2, "u-1", "notification.created", `{"unread_count":2}`)
+	other := entity.NewOutboxEvent("flaky", 3, "u-2", "notification.created", `{"unread_count":1}`)
+	for _, ev := range []*entity.OutboxEvent{first, second, other} {
+		_ = repo.Create(context.Background(), ev)
+	}
+
+	// The first event fails; the second must wait for it while other users
+	// are not held up.
+	relay.drain(context.Background())
+	if first.Status != entity.OutboxStatusPending || second.Attempts != 0 || other.Status != entity.OutboxStatusSent {
+		t.Fatalf("unexpected state first=%+v second=%+v other=%+v", first, second, other)
+	}
+
+	first.NextAttemptAt = time.Now()
+	relay.drain(context.Background())
+	if want := []uint64{other.ID, first.ID, second.ID}; !slices.Equal(sink.delivered, want) {
+		t.Fatalf("delivered %v, want %v", sink.delivered, want)
+	}
+}
+
+func TestOutboxRelayPurgesExpiredEvents(t *testing.T) {
+	repo := &fakeOutboxRepo{events: map[uint64]*entity.OutboxEvent{}}
+	relay := NewOutboxRelay(fakeTx{}, repo, config.OutboxConfig{Retention: time.Hour}, &flakySink{})
+
+	old := time.Now().Add(-2 * time.Hour)
+	var rows []*entity.OutboxEvent
+	for _, status := range []entity.OutboxStatus{entity.OutboxStatusSent, entity.OutboxStatusDead, entity.OutboxStatusPending} {
+		ev := entity.NewOutboxEvent("flaky", 1, "u-1", "notification.created", `{}`)
+		ev.Status, ev.CreatedAt = status, old
+		rows = append(rows, ev)
+	}
+	for i := 0; i < outboxPurgeBatch+1; i++ {
+		ev := entity.NewOutboxEvent("flaky", 2, "u-2", "notification.created", `{}`)
+		ev.Status, ev.CreatedAt = entity.OutboxStatusSent, old
+		rows = append(rows, ev)
+	}
+	recent := entity.NewOutboxEvent("flaky", 3, "u-3", "notification.created", `{}`)
+	recent.Status = entity.OutboxStatusSent
+	rows = append(rows, recent)
+	for _, ev := range rows {
+		_ = repo.Create(context.Background(), ev)
+	}
+
+	relay.purge(context.Background())
+	// Only the old pending row and the row within retention remain, across
+	// several purge batches.
+	if len(repo.events) != 2 || repo.events[rows[2].ID] == nil || repo.events[recent.ID] == nil {
+		t.Fatalf("unexpected rows after purge: %d", len(repo.events))
+	}
+}
+
+func TestOutboxRelayBackoffIsCapped(t *testing.T) {
+	relay := NewOutboxRelay(fakeTx{}, nil, config.OutboxConfig{BaseBackoff: time.Second, MaxBackoff: 5 * time.Second})
+	if got := relay.backoff(1); got != time.Second {
+		t.Fatalf("backoff(1) = %s", got)
+	}
+	if got := relay.backoff(3); got != 4*time.Second {
+		t.Fatalf("backoff(3) = %s", got)
+	}
+	if got := relay.backoff(10); got != 5*time.Second {
+		t.Fatalf("backoff(10) = %s", got)
+	}
+}
@@ -1,5 +1,7 @@
 package cqe
 
+import "strings"
+
 // ListNotificationsReq 列表查询请求。
 type ListNotificationsReq struct {
 	Page     int `form:"page"`
@@ -31,6 +33,8 @@ type CreateNotificationReq struct {
 	Title     string `json:"title"`
 	Content   string `json:"content"`
 	ExtraJSON string `json:"extra_json,omitempty"`
+	// EventID 来源事件 ID，非空时同一事件只创建一条通知，重复创建返回 ErrConflict。
+	EventID string `json:"event_id,omitempty"`
 }
 
 // Validate 校验必填字段是否完整。
@@ -40,3 +44,16 @@ func (r *CreateNotificationReq) Validate() bool {
 	}
 	return r.UserUUID != "" && r.Type != "" && r.Title != "" && r.Content != ""
 }
+
+// OpsActionReq 运维对单条通知的写操作（强制已读、删除、重发）。
+type OpsActionReq struct {
+	// Operator 与 NotificationID 来自运维凭证和路径，不从请求体读取。
+	Operator       string `json:"-"`
+	NotificationID uint64 `json:"-"`
+	Reason         string `json:"reason"`
+}
+
+// Validate 校验操作原因已填写，用于审计。
+func (r *OpsActionReq) Validate() bool {
+	return r != nil && r.Operator != "" && r.NotificationID != 0 && strings.TrimSpace(r.Reason) != ""
+}
@@ -14,8 +14,67 @@ type NotificationDto struct {
 	ReadAt    *time.Time `json:"read_at,omitempty"`
 }
 
+// CreateNotificationResponse 创建通知后返回给生产方的标识信息。
+type CreateNotificationResponse struct {
+	ID        uint64    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // ListNotificationsResponse 列表响应结构，包含未读数。
 type ListNotificationsResponse struct {
 	Notifications []NotificationDto `json:"notifications"`
 	UnreadCount   int64             `json:"unread_count"`
 }
+
+// OpsNotificationDto 运维视角的通知，包含删除状态与各 sink 的投递记录。
+type OpsNotificationDto struct {
+	NotificationDto
+	UserUUID   string              `json:"user_uuid"`
+	DeletedAt  *time.Time          `json:"deleted_at,omitempty"`
+	Deliveries []OutboxDeliveryDto `json:"deliveries"`
+}
+
+// OutboxDeliveryDto 一条生命周期事件在某个 sink 上的投递状态。
+type OutboxDeliveryDto struct {
+	Sink          string     `json:"sink"`
+	EventType     string     `json:"event_type"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
+
+// OpsAuditDto 运维操作记录。
+type OpsAuditDto struct {
+	Operator       string    `json:"operator"`
+	Action         string    `json:"action"`
+	NotificationID uint64    `json:"notification_id"`
+	Reason         string    `json:"reason"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// OpsUserNotificationsResponse 运维查询用户通知的响应。
+type OpsUserNotificationsResponse struct {
+	Notifications []OpsNotificationDto `json:"notifications"`
+	UnreadCount   int64                `json:"unread_count"`
+	Audit         []OpsAuditDto        `json:"audit"`
+}
+
+// TypeCountDto 某一通知类型的计数。
+type TypeCountDto struct {
+	Type    string `json:"type"`
+	Total   int64  `json:"total"`
+	Unread  int64  `json:"unread"`
+	Deleted int64  `json:"deleted"`
+}
+
+// OpsUserStatsResponse 用户通知计数，Total/Unread/Deleted 为各类型之和。
+type OpsUserStatsResponse struct {
+	UserUUID string         `json:"user_uuid"`
+	Total    int64          `json:"total"`
+	Unread   int64          `json:"unread"`
+	Deleted  int64          `json:"deleted"`
+	ByType   []TypeCountDto `json:"by_type"`
+}
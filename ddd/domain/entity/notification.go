@@ -10,9 +10,21 @@ type Notification struct {
 	Title     string
 	Content   string
 	ExtraJSON string
+	// EventID 来源事件 ID，用于消费重投时去重；为空表示不去重。
+	EventID   string
 	IsRead    bool
 	CreatedAt time.Time
 	ReadAt    *time.Time
+	// DeletedAt 非空表示已被删除，不再出现在用户列表和未读数中。
+	DeletedAt *time.Time
+}
+
+// TypeCount 用户某一通知类型的计数。
+type TypeCount struct {
+	Type    string
+	Total   int64
+	Unread  int64
+	Deleted int64
 }
 
 // NewNotification 创建一条新的未读通知。
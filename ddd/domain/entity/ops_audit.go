@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// 运维操作类型。
+const (
+	OpsActionMarkRead = "mark_read"
+	OpsActionDelete   = "delete"
+	OpsActionResend   = "resend"
+)
+
+// OpsAudit 运维人员对通知的一次写操作及原因，用于事后追溯。
+type OpsAudit struct {
+	ID             uint64
+	Operator       string
+	Action         string
+	UserUUID       string
+	NotificationID uint64
+	Reason         string
+	CreatedAt      time.Time
+}
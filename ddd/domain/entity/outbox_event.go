@@ -0,0 +1,43 @@
+package entity
+
+import "time"
+
+// OutboxStatus 表示 outbox 事件的投递状态。
+type OutboxStatus int
+
+const (
+	OutboxStatusPending OutboxStatus = iota
+	OutboxStatusSent
+	// OutboxStatusDead 超过最大重试次数后不再投递，保留以便排查。
+	OutboxStatusDead
+)
+
+// OutboxEvent 与业务数据在同一事务内写入的待发布事件。
+// 每个 sink 各有一行，投递与重试互不影响。
+type OutboxEvent struct {
+	ID            uint64
+	Sink          string
+	AggregateID   uint64
+	UserUUID      string
+	EventType     string
+	Payload       string
+	Status        OutboxStatus
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+	SentAt        *time.Time
+}
+
+// NewOutboxEvent 创建一条待发布的 outbox 事件。
+func NewOutboxEvent(sink string, aggregateID uint64, userUUID, eventType, payload string) *OutboxEvent {
+	return &OutboxEvent{
+		Sink:          sink,
+		AggregateID:   aggregateID,
+		UserUUID:      userUUID,
+		EventType:     eventType,
+		Payload:       payload,
+		Status:        OutboxStatusPending,
+		NextAttemptAt: time.Now(),
+	}
+}
@@ -0,0 +1,48 @@
+package event
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SchemaVersion 生命周期事件信封的版本号，字段发生不兼容变更时递增。
+const SchemaVersion = 1
+
+// 通知生命周期事件类型。
+const (
+	TypeNotificationCreated = "notification.created"
+	TypeNotificationRead    = "notification.read"
+	TypeNotificationDeleted = "notification.deleted"
+)
+
+// NotificationEvent 通知状态变更事件，写入 outbox 后由各个 sink 投递
+// （SSE 推送、Kafka 生命周期流等）。
+type NotificationEvent struct {
+	SchemaVersion    int       `json:"schema_version"`
+	EventID          string    `json:"event_id"`
+	EventType        string    `json:"event_type"`
+	UserUUID         string    `json:"user_uuid"`
+	NotificationIDs  []uint64  `json:"notification_ids"`
+	NotificationType string    `json:"notification_type,omitempty"`
+	UnreadCount      int64     `json:"unread_count"`
+	RequestID        string    `json:"request_id,omitempty"`
+	OccurredAt       time.Time `json:"occurred_at"`
+	// NoPush 表示通知因频控降级，只落库、不做实时弹出推送。
+	NoPush bool `json:"no_push,omitempty"`
+	// TraceContext 产生事件的请求的 W3C trace context（traceparent 等），
+	// relay 异步投递时据此接回原链路。
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+}
+
+// NewNotificationEvent 创建一条带唯一 ID 的生命周期事件。
+func NewNotificationEvent(eventType, userUUID string, ids []uint64) *NotificationEvent {
+	return &NotificationEvent{
+		SchemaVersion:   SchemaVersion,
+		EventID:         uuid.NewString(),
+		EventType:       eventType,
+		UserUUID:        userUUID,
+		NotificationIDs: ids,
+		OccurredAt:      time.Now().UTC(),
+	}
+}
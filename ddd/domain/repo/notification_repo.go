@@ -2,14 +2,32 @@ package repo
 
 import (
 	"context"
+	"errors"
 
 	"notification-service/ddd/domain/entity"
 )
 
+// ErrDuplicateEvent 表示同一来源事件的通知已经存在。
+var ErrDuplicateEvent = errors.New("notification for event already exists")
+
 // NotificationRepository 通知仓储接口，隐藏具体持久化实现。
 type NotificationRepository interface {
+	// Create 持久化通知，成功后回填 n.ID 与 n.CreatedAt；n.EventID 已存在时
+	// 返回 ErrDuplicateEvent。
 	Create(ctx context.Context, n *entity.Notification) error
 	ListByUser(ctx context.Context, userUUID string, offset, limit int) ([]*entity.Notification, error)
 	CountUnread(ctx context.Context, userUUID string) (int64, error)
-	MarkRead(ctx context.Context, userUUID string, ids []uint64) error
+	// MarkRead 把属于该用户的未读通知标记为已读，返回实际更新的通知 ID。
+	MarkRead(ctx context.Context, userUUID string, ids []uint64) ([]uint64, error)
+	// SoftDelete 标记通知为已删除，返回实际更新的条数；已删除的通知不出现在列表和未读数中。
+	SoftDelete(ctx context.Context, userUUID string, ids []uint64) (int64, error)
+
+	// 以下查询包含已删除的通知，供运维排查使用。
+
+	// Get 按 ID 查询通知，不存在时返回 nil。
+	Get(ctx context.Context, id uint64) (*entity.Notification, error)
+	// GetForUpdate 与 Get 相同，在事务中调用时锁定该通知直到事务结束。
+	GetForUpdate(ctx context.Context, id uint64) (*entity.Notification, error)
+	ListAllByUser(ctx context.Context, userUUID string, offset, limit int) ([]*entity.Notification, error)
+	CountByType(ctx context.Context, userUUID string) ([]entity.TypeCount, error)
 }
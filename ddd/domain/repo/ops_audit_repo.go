@@ -0,0 +1,14 @@
+package repo
+
+import (
+	"context"
+
+	"notification-service/ddd/domain/entity"
+)
+
+// OpsAuditRepository 运维审计记录仓储接口。
+type OpsAuditRepository interface {
+	Create(ctx context.Context, a *entity.OpsAudit) error
+	// ListByUser 按时间倒序返回用户最近的审计记录。
+	ListByUser(ctx context.Context, userUUID string, limit int) ([]*entity.OpsAudit, error)
+}
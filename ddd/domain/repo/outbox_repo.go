@@ -0,0 +1,27 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"notification-service/ddd/domain/entity"
+)
+
+// OutboxRepository outbox 事件仓储接口。
+type OutboxRepository interface {
+	Create(ctx context.Context, ev *entity.OutboxEvent) error
+	// ClaimDue 在短事务中领取到期的待发布事件，并把它们的 next_attempt_at 推迟到
+	// leaseUntil 作为租约；事务提交后即释放行锁，投递在事务外进行，relay 在租约
+	// 到期前退出时事件会被重新领取。同一 sink 下每个用户只领取最早的一条待发布
+	// 事件，前一条投递成功或进入死信之前后续事件不会被领取，保证未读数等状态按
+	// 写入顺序投递。
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*entity.OutboxEvent, error)
+	MarkSent(ctx context.Context, id uint64, sentAt time.Time) error
+	MarkRetry(ctx context.Context, id uint64, attempts int, nextAttemptAt time.Time, lastErr string) error
+	MarkDead(ctx context.Context, id uint64, attempts int, lastErr string) error
+	// PurgeBefore 删除 before 之前创建的已投递与死信事件，每次最多 limit 行，
+	// 返回删除的行数；待投递事件不受影响。
+	PurgeBefore(ctx context.Context, before time.Time, limit int) (int64, error)
+	// ListByAggregates 返回这些通知的全部 outbox 事件（含保留期内已投递的），供运维排查。
+	ListByAggregates(ctx context.Context, aggregateIDs []uint64) ([]*entity.OutboxEvent, error)
+}
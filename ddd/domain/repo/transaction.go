@@ -0,0 +1,8 @@
+package repo
+
+import "context"
+
+// TransactionManager 事务管理接口，fn 内使用传入的 ctx 调用仓储即可加入同一事务。
+type TransactionManager interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -2,12 +2,14 @@ package dao
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"notification-service/ddd/infrastructure/database/po"
 	"notification-service/internal/resource"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type NotificationDao struct {
@@ -18,14 +20,19 @@ func NewNotificationDao() *NotificationDao {
 	return &NotificationDao{db: resource.MainDB()}
 }
 
+// conn 返回当前调用应使用的连接：ctx 中存在事务时加入该事务。
+func (d *NotificationDao) conn(ctx context.Context) *gorm.DB {
+	return resource.DBFromContext(ctx, d.db).WithContext(ctx)
+}
+
 func (d *NotificationDao) Create(ctx context.Context, p *po.Notification) error {
-	return d.db.WithContext(ctx).Create(p).Error
+	return d.conn(ctx).Create(p).Error
 }
 
 func (d *NotificationDao) ListByUser(ctx context.Context, userUUID string, offset, limit int) ([]po.Notification, error) {
 	var pos []po.Notification
-	err := d.db.WithContext(ctx).
-		Where("user_uuid = ?", userUUID).
+	err := d.conn(ctx).
+		Where("user_uuid = ? AND deleted_at IS NULL", userUUID).
 		Order("created_at DESC").
 		Offset(offset).Limit(limit).
 		Find(&pos).Error
@@ -37,23 +44,115 @@ func (d *NotificationDao) ListByUser(ctx context.Context, userUUID string, offse
 
 func (d *NotificationDao) CountUnread(ctx context.Context, userUUID string) (int64, error) {
 	var count int64
-	err := d.db.WithContext(ctx).
+	err := d.conn(ctx).
 		Model(&po.Notification{}).
-		Where("user_uuid = ? AND is_read = 0", userUUID).
+		Where("user_uuid = ? AND is_read = 0 AND deleted_at IS NULL", userUUID).
 		Count(&count).Error
 	return count, err
 }
 
-func (d *NotificationDao) MarkRead(ctx context.Context, userUUID string, ids []uint64) error {
+// MarkRead 把未读通知标记为已读，已读通知保留首次阅读时间；返回实际更新的通知 ID。
+// 需在事务中调用，先锁定待更新的行，保证返回的 ID 与更新的行一致。
+func (d *NotificationDao) MarkRead(ctx context.Context, userUUID string, ids []uint64) ([]uint64, error) {
 	if len(ids) == 0 {
-		return nil
+		return nil, nil
+	}
+	var unread []uint64
+	err := d.conn(ctx).
+		Model(&po.Notification{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_uuid = ? AND id IN ? AND deleted_at IS NULL AND is_read = ?", userUUID, ids, false).
+		Order("id").
+		Pluck("id", &unread).Error
+	if err != nil || len(unread) == 0 {
+		return nil, err
 	}
-	now := time.Now()
-	return d.db.WithContext(ctx).
+	err = d.conn(ctx).
 		Model(&po.Notification{}).
-		Where("user_uuid = ? AND id IN ?", userUUID, ids).
+		Where("id IN ?", unread).
 		Updates(map[string]interface{}{
 			"is_read": true,
-			"read_at": now,
+			"read_at": time.Now(),
 		}).Error
+	if err != nil {
+		return nil, err
+	}
+	return unread, nil
+}
+
+// SoftDelete 软删除通知，返回实际更新的行数。
+func (d *NotificationDao) SoftDelete(ctx context.Context, userUUID string, ids []uint64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	res := d.conn(ctx).
+		Model(&po.Notification{}).
+		Where("user_uuid = ? AND id IN ? AND deleted_at IS NULL", userUUID, ids).
+		Update("deleted_at", time.Now())
+	return res.RowsAffected, res.Error
+}
+
+// Get 按主键查询（包含已删除），不存在时返回 nil。
+func (d *NotificationDao) Get(ctx context.Context, id uint64) (*po.Notification, error) {
+	var p po.Notification
+	err := d.conn(ctx).Where("id = ?", id).First(&p).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetForUpdate 与 Get 相同，但使用 SELECT ... FOR UPDATE 锁定该行直到事务结束。
+func (d *NotificationDao) GetForUpdate(ctx context.Context, id uint64) (*po.Notification, error) {
+	var p po.Notification
+	err := d.conn(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&p).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListAllByUser 与 ListByUser 相同，但包含已删除的通知。
+func (d *NotificationDao) ListAllByUser(ctx context.Context, userUUID string, offset, limit int) ([]po.Notification, error) {
+	var pos []po.Notification
+	err := d.conn(ctx).
+		Where("user_uuid = ?", userUUID).
+		Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&pos).Error
+	if err != nil {
+		return nil, err
+	}
+	return pos, nil
+}
+
+// TypeCount 按类型聚合的计数行。
+type TypeCount struct {
+	Type    string `gorm:"column:type"`
+	Total   int64  `gorm:"column:total"`
+	Unread  int64  `gorm:"column:unread"`
+	Deleted int64  `gorm:"column:deleted"`
+}
+
+func (d *NotificationDao) CountByType(ctx context.Context, userUUID string) ([]TypeCount, error) {
+	var rows []TypeCount
+	err := d.conn(ctx).
+		Model(&po.Notification{}).
+		Select("type, COUNT(*) AS total, "+
+			"SUM(CASE WHEN is_read = 0 AND deleted_at IS NULL THEN 1 ELSE 0 END) AS unread, "+
+			"SUM(CASE WHEN deleted_at IS NOT NULL THEN 1 ELSE 0 END) AS deleted").
+		Where("user_uuid = ?", userUUID).
+		Group("type").
+		Order("type").
+		Scan(&rows).Error
+	return rows, err
 }
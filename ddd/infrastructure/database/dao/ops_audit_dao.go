@@ -0,0 +1,39 @@
+package dao
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"notification-service/ddd/infrastructure/database/po"
+	"notification-service/internal/resource"
+)
+
+type OpsAuditDao struct {
+	db *gorm.DB
+}
+
+func NewOpsAuditDao() *OpsAuditDao {
+	return &OpsAuditDao{db: resource.MainDB()}
+}
+
+func (d *OpsAuditDao) conn(ctx context.Context) *gorm.DB {
+	return resource.DBFromContext(ctx, d.db).WithContext(ctx)
+}
+
+func (d *OpsAuditDao) Create(ctx context.Context, p *po.OpsAudit) error {
+	return d.conn(ctx).Create(p).Error
+}
+
+func (d *OpsAuditDao) ListByUser(ctx context.Context, userUUID string, limit int) ([]po.OpsAudit, error) {
+	var pos []po.OpsAudit
+	err := d.conn(ctx).
+		Where("user_uuid = ?", userUUID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&pos).Error
+	if err != nil {
+		return nil, err
+	}
+	return pos, nil
+}
@@ -0,0 +1,124 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"notification-service/ddd/infrastructure/database/po"
+	"notification-service/internal/resource"
+)
+
+const (
+	outboxStatusPending = 0
+	outboxStatusSent    = 1
+	outboxStatusDead    = 2
+)
+
+type OutboxDao struct {
+	db *gorm.DB
+}
+
+func NewOutboxDao() *OutboxDao {
+	return &OutboxDao{db: resource.MainDB()}
+}
+
+func (d *OutboxDao) conn(ctx context.Context) *gorm.DB {
+	return resource.DBFromContext(ctx, d.db).WithContext(ctx)
+}
+
+func (d *OutboxDao) Create(ctx context.Context, p *po.OutboxEvent) error {
+	return d.conn(ctx).Create(p).Error
+}
+
+// ClaimDue 使用 FOR UPDATE SKIP LOCKED 领取到期事件，多实例并发时互不阻塞；
+// 同一 sink 与用户下还有更早的待发布事件时跳过（idx_sink_user_status），
+// 并把领取到的行的 next_attempt_at 推迟到 leaseUntil。需在事务中调用。
+func (d *OutboxDao) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]po.OutboxEvent, error) {
+	var pos []po.OutboxEvent
+	err := d.conn(ctx).
+		Table("notification_outbox AS o").
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("o.status = ? AND o.next_attempt_at <= ?", outboxStatusPending, now).
+		Where("NOT EXISTS (SELECT 1 FROM notification_outbox AS p "+
+			"WHERE p.sink = o.sink AND p.user_uuid = o.user_uuid AND p.status = ? AND p.id < o.id)", outboxStatusPending).
+		Order("o.id ASC").
+		Limit(limit).
+		Find(&pos).Error
+	if err != nil || len(pos) == 0 {
+		return nil, err
+	}
+
+	ids := make([]uint64, 0, len(pos))
+	for i := range pos {
+		ids = append(ids, pos[i].ID)
+		pos[i].NextAttemptAt = leaseUntil
+	}
+	err = d.conn(ctx).
+		Model(&po.OutboxEvent{}).
+		Where("id IN ?", ids).
+		Update("next_attempt_at", leaseUntil).Error
+	if err != nil {
+		return nil, err
+	}
+	return pos, nil
+}
+
+func (d *OutboxDao) MarkSent(ctx context.Context, id uint64, sentAt time.Time) error {
+	return d.conn(ctx).
+		Model(&po.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":  outboxStatusSent,
+			"sent_at": sentAt,
+		}).Error
+}
+
+func (d *OutboxDao) MarkRetry(ctx context.Context, id uint64, attempts int, nextAttemptAt time.Time, lastErr string) error {
+	return d.conn(ctx).
+		Model(&po.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastErr,
+		}).Error
+}
+
+// PurgeBefore 删除 before 之前创建的已投递与死信事件（idx_status_created），
+// 每次最多 limit 行，返回删除的行数。
+func (d *OutboxDao) PurgeBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	res := d.conn(ctx).Exec(
+		"DELETE FROM notification_outbox WHERE status IN (?, ?) AND created_at < ? ORDER BY id LIMIT ?",
+		outboxStatusSent, outboxStatusDead, before, limit)
+	return res.RowsAffected, res.Error
+}
+
+// ListByAggregates 按通知 ID 查询全部 outbox 事件（idx_aggregate_id）。
+func (d *OutboxDao) ListByAggregates(ctx context.Context, aggregateIDs []uint64) ([]po.OutboxEvent, error) {
+	if len(aggregateIDs) == 0 {
+		return nil, nil
+	}
+	var pos []po.OutboxEvent
+	err := d.conn(ctx).
+		Where("aggregate_id IN ?", aggregateIDs).
+		Order("id ASC").
+		Find(&pos).Error
+	if err != nil {
+		return nil, err
+	}
+	return pos, nil
+}
+
+func (d *OutboxDao) MarkDead(ctx context.Context, id uint64, attempts int, lastErr string) error {
+	return d.conn(ctx).
+		Model(&po.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     outboxStatusDead,
+			"attempts":   attempts,
+			"last_error": lastErr,
+		}).Error
+}
@@ -2,6 +2,10 @@ package persistence
 
 import (
 	"context"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+
 	"notification-service/ddd/domain/entity"
 	drepo "notification-service/ddd/domain/repo"
 	"notification-service/ddd/infrastructure/database/dao"
@@ -25,7 +29,18 @@ func (r *notificationRepositoryImpl) Create(ctx context.Context, n *entity.Notif
 		ExtraJSON: n.ExtraJSON,
 		IsRead:    n.IsRead,
 	}
-	return r.dao.Create(ctx, p)
+	if n.EventID != "" {
+		p.EventID = &n.EventID
+	}
+	if err := r.dao.Create(ctx, p); err != nil {
+		if n.EventID != "" && isDuplicateKey(err) {
+			return drepo.ErrDuplicateEvent
+		}
+		return err
+	}
+	n.ID = p.ID
+	n.CreatedAt = p.CreatedAt
+	return nil
 }
 
 func (r *notificationRepositoryImpl) ListByUser(ctx context.Context, userUUID string, offset, limit int) ([]*entity.Notification, error) {
@@ -34,19 +49,8 @@ func (r *notificationRepositoryImpl) ListByUser(ctx context.Context, userUUID st
 		return nil, err
 	}
 	res := make([]*entity.Notification, 0, len(pos))
-	for _, p := range pos {
-		n := &entity.Notification{
-			ID:        p.ID,
-			UserUUID:  p.UserUUID,
-			Type:      p.Type,
-			Title:     p.Title,
-			Content:   p.Content,
-			ExtraJSON: p.ExtraJSON,
-			IsRead:    p.IsRead,
-			CreatedAt: p.CreatedAt,
-			ReadAt:    p.ReadAt,
-		}
-		res = append(res, n)
+	for i := range pos {
+		res = append(res, toNotificationEntity(&pos[i]))
 	}
 	return res, nil
 }
@@ -55,6 +59,80 @@ func (r *notificationRepositoryImpl) CountUnread(ctx context.Context, userUUID s
 	return r.dao.CountUnread(ctx, userUUID)
 }
 
-func (r *notificationRepositoryImpl) MarkRead(ctx context.Context, userUUID string, ids []uint64) error {
+func (r *notificationRepositoryImpl) MarkRead(ctx context.Context, userUUID string, ids []uint64) ([]uint64, error) {
 	return r.dao.MarkRead(ctx, userUUID, ids)
 }
+
+func (r *notificationRepositoryImpl) SoftDelete(ctx context.Context, userUUID string, ids []uint64) (int64, error) {
+	return r.dao.SoftDelete(ctx, userUUID, ids)
+}
+
+func (r *notificationRepositoryImpl) Get(ctx context.Context, id uint64) (*entity.Notification, error) {
+	p, err := r.dao.Get(ctx, id)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	return toNotificationEntity(p), nil
+}
+
+func (r *notificationRepositoryImpl) GetForUpdate(ctx context.Context, id uint64) (*entity.Notification, error) {
+	p, err := r.dao.GetForUpdate(ctx, id)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	return toNotificationEntity(p), nil
+}
+
+func (r *notificationRepositoryImpl) ListAllByUser(ctx context.Context, userUUID string, offset, limit int) ([]*entity.Notification, error) {
+	pos, err := r.dao.ListAllByUser(ctx, userUUID, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*entity.Notification, 0, len(pos))
+	for i := range pos {
+		res = append(res, toNotificationEntity(&pos[i]))
+	}
+	return res, nil
+}
+
+func (r *notificationRepositoryImpl) CountByType(ctx context.Context, userUUID string) ([]entity.TypeCount, error) {
+	rows, err := r.dao.CountByType(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]entity.TypeCount, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, entity.TypeCount{
+			Type:    row.Type,
+			Total:   row.Total,
+			Unread:  row.Unread,
+			Deleted: row.Deleted,
+		})
+	}
+	return res, nil
+}
+
+// isDuplicateKey 判断 MySQL 唯一键冲突（1062）。
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+func toNotificationEntity(p *po.Notification) *entity.Notification {
+	n := &entity.Notification{
+		ID:        p.ID,
+		UserUUID:  p.UserUUID,
+		Type:      p.Type,
+		Title:     p.Title,
+		Content:   p.Content,
+		ExtraJSON: p.ExtraJSON,
+		IsRead:    p.IsRead,
+		CreatedAt: p.CreatedAt,
+		ReadAt:    p.ReadAt,
+		DeletedAt: p.DeletedAt,
+	}
+	if p.EventID != nil {
+		n.EventID = *p.EventID
+	}
+	return n
+}
@@ -0,0 +1,57 @@
+package persistence
+
+import (
+	"context"
+
+	"notification-service/ddd/domain/entity"
+	drepo "notification-service/ddd/domain/repo"
+	"notification-service/ddd/infrastructure/database/dao"
+	"notification-service/ddd/infrastructure/database/po"
+)
+
+// maxOpsReasonLen 与 reason 列宽保持一致。
+const maxOpsReasonLen = 512
+
+type opsAuditRepositoryImpl struct {
+	dao *dao.OpsAuditDao
+}
+
+func NewOpsAuditRepository() drepo.OpsAuditRepository {
+	return &opsAuditRepositoryImpl{dao: dao.NewOpsAuditDao()}
+}
+
+func (r *opsAuditRepositoryImpl) Create(ctx context.Context, a *entity.OpsAudit) error {
+	p := &po.OpsAudit{
+		Operator:       a.Operator,
+		Action:         a.Action,
+		UserUUID:       a.UserUUID,
+		NotificationID: a.NotificationID,
+		Reason:         truncate(a.Reason, maxOpsReasonLen),
+	}
+	if err := r.dao.Create(ctx, p); err != nil {
+		return err
+	}
+	a.ID = p.ID
+	a.CreatedAt = p.CreatedAt
+	return nil
+}
+
+func (r *opsAuditRepositoryImpl) ListByUser(ctx context.Context, userUUID string, limit int) ([]*entity.OpsAudit, error) {
+	pos, err := r.dao.ListByUser(ctx, userUUID, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*entity.OpsAudit, 0, len(pos))
+	for _, p := range pos {
+		res = append(res, &entity.OpsAudit{
+			ID:             p.ID,
+			Operator:       p.Operator,
+			Action:         p.Action,
+			UserUUID:       p.UserUUID,
+			NotificationID: p.NotificationID,
+			Reason:         p.Reason,
+			CreatedAt:      p.CreatedAt,
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,105 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"notification-service/ddd/domain/entity"
+	drepo "notification-service/ddd/domain/repo"
+	"notification-service/ddd/infrastructure/database/dao"
+	"notification-service/ddd/infrastructure/database/po"
+)
+
+// maxOutboxErrorLen 与 last_error 列宽保持一致。
+const maxOutboxErrorLen = 512
+
+type outboxRepositoryImpl struct {
+	dao *dao.OutboxDao
+}
+
+func NewOutboxRepository() drepo.OutboxRepository {
+	return &outboxRepositoryImpl{dao: dao.NewOutboxDao()}
+}
+
+func (r *outboxRepositoryImpl) Create(ctx context.Context, ev *entity.OutboxEvent) error {
+	p := &po.OutboxEvent{
+		Sink:          ev.Sink,
+		AggregateID:   ev.AggregateID,
+		UserUUID:      ev.UserUUID,
+		EventType:     ev.EventType,
+		Payload:       ev.Payload,
+		Status:        int(ev.Status),
+		Attempts:      ev.Attempts,
+		NextAttemptAt: ev.NextAttemptAt,
+	}
+	if err := r.dao.Create(ctx, p); err != nil {
+		return err
+	}
+	ev.ID = p.ID
+	ev.CreatedAt = p.CreatedAt
+	return nil
+}
+
+func (r *outboxRepositoryImpl) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*entity.OutboxEvent, error) {
+	pos, err := r.dao.ClaimDue(ctx, now, leaseUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*entity.OutboxEvent, 0, len(pos))
+	for i := range pos {
+		res = append(res, toOutboxEntity(&pos[i]))
+	}
+	return res, nil
+}
+
+func (r *outboxRepositoryImpl) MarkSent(ctx context.Context, id uint64, sentAt time.Time) error {
+	return r.dao.MarkSent(ctx, id, sentAt)
+}
+
+func (r *outboxRepositoryImpl) MarkRetry(ctx context.Context, id uint64, attempts int, nextAttemptAt time.Time, lastErr string) error {
+	return r.dao.MarkRetry(ctx, id, attempts, nextAttemptAt, truncate(lastErr, maxOutboxErrorLen))
+}
+
+func (r *outboxRepositoryImpl) MarkDead(ctx context.Context, id uint64, attempts int, lastErr string) error {
+	return r.dao.MarkDead(ctx, id, attempts, truncate(lastErr, maxOutboxErrorLen))
+}
+
+func (r *outboxRepositoryImpl) PurgeBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return r.dao.PurgeBefore(ctx, before, limit)
+}
+
+func (r *outboxRepositoryImpl) ListByAggregates(ctx context.Context, aggregateIDs []uint64) ([]*entity.OutboxEvent, error) {
+	pos, err := r.dao.ListByAggregates(ctx, aggregateIDs)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*entity.OutboxEvent, 0, len(pos))
+	for i := range pos {
+		res = append(res, toOutboxEntity(&pos[i]))
+	}
+	return res, nil
+}
+
+func toOutboxEntity(p *po.OutboxEvent) *entity.OutboxEvent {
+	return &entity.OutboxEvent{
+		ID:            p.ID,
+		Sink:          p.Sink,
+		AggregateID:   p.AggregateID,
+		UserUUID:      p.UserUUID,
+		EventType:     p.EventType,
+		Payload:       p.Payload,
+		Status:        entity.OutboxStatus(p.Status),
+		Attempts:      p.Attempts,
+		NextAttemptAt: p.NextAttemptAt,
+		LastError:     p.LastError,
+		CreatedAt:     p.CreatedAt,
+		SentAt:        p.SentAt,
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
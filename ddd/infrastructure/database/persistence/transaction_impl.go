@@ -0,0 +1,24 @@
+package persistence
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	drepo "notification-service/ddd/domain/repo"
+	"notification-service/internal/resource"
+)
+
+type transactionManagerImpl struct {
+	db *gorm.DB
+}
+
+func NewTransactionManager() drepo.TransactionManager {
+	return &transactionManagerImpl{db: resource.MainDB()}
+}
+
+func (m *transactionManagerImpl) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return resource.DBFromContext(ctx, m.db).WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(resource.ContextWithTx(ctx, tx))
+	})
+}
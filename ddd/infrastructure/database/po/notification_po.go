@@ -3,6 +3,15 @@ package po
 import "time"
 
 // Notification 持久化对象，对应 notifications 表。
+//
+// 软删除字段：
+//
+//	ALTER TABLE notifications ADD COLUMN deleted_at DATETIME(3) NULL;
+//
+// 来源事件去重（NULL 不参与唯一约束）：
+//
+//	ALTER TABLE notifications ADD COLUMN event_id VARCHAR(128) NULL,
+//	  ADD UNIQUE KEY uk_event_id (event_id);
 type Notification struct {
 	ID        uint64     `gorm:"column:id;primaryKey;autoIncrement"`
 	UserUUID  string     `gorm:"column:user_uuid"`
@@ -10,9 +19,11 @@ type Notification struct {
 	Title     string     `gorm:"column:title"`
 	Content   string     `gorm:"column:content"`
 	ExtraJSON string     `gorm:"column:extra_json"`
+	EventID   *string    `gorm:"column:event_id"`
 	IsRead    bool       `gorm:"column:is_read"`
 	CreatedAt time.Time  `gorm:"column:created_at"`
 	ReadAt    *time.Time `gorm:"column:read_at"`
+	DeletedAt *time.Time `gorm:"column:deleted_at"`
 }
 
 func (Notification) TableName() string {
@@ -0,0 +1,29 @@
+package po
+
+import "time"
+
+// OpsAudit 持久化对象，对应 notification_ops_audit 表。
+//
+//	CREATE TABLE notification_ops_audit (
+//	  id              BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+//	  operator        VARCHAR(64)  NOT NULL,
+//	  action          VARCHAR(32)  NOT NULL,
+//	  user_uuid       VARCHAR(64)  NOT NULL,
+//	  notification_id BIGINT UNSIGNED NOT NULL,
+//	  reason          VARCHAR(512) NOT NULL,
+//	  created_at      DATETIME(3)  NOT NULL,
+//	  KEY idx_user_created (user_uuid, created_at)
+//	);
+type OpsAudit struct {
+	ID             uint64    `gorm:"column:id;primaryKey;autoIncrement"`
+	Operator       string    `gorm:"column:operator"`
+	Action         string    `gorm:"column:action"`
+	UserUUID       string    `gorm:"column:user_uuid"`
+	NotificationID uint64    `gorm:"column:notification_id"`
+	Reason         string    `gorm:"column:reason"`
+	CreatedAt      time.Time `gorm:"column:created_at"`
+}
+
+func (OpsAudit) TableName() string {
+	return "notification_ops_audit"
+}
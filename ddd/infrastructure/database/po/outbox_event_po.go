@@ -0,0 +1,47 @@
+package po
+
+import "time"
+
+// OutboxEvent 持久化对象，对应 notification_outbox 表。
+//
+//	CREATE TABLE notification_outbox (
+//	  id              BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+//	  sink            VARCHAR(32)  NOT NULL,
+//	  aggregate_id    BIGINT UNSIGNED NOT NULL,
+//	  user_uuid       VARCHAR(64)  NOT NULL,
+//	  event_type      VARCHAR(64)  NOT NULL,
+//	  payload         JSON         NOT NULL,
+//	  status          TINYINT      NOT NULL DEFAULT 0,
+//	  attempts        INT          NOT NULL DEFAULT 0,
+//	  next_attempt_at DATETIME(3)  NOT NULL,
+//	  last_error      VARCHAR(512) NOT NULL DEFAULT '',
+//	  created_at      DATETIME(3)  NOT NULL,
+//	  sent_at         DATETIME(3)  NULL,
+//	  KEY idx_status_next_attempt (status, next_attempt_at),
+//	  KEY idx_sink_user_status (sink, user_uuid, status, id),
+//	  KEY idx_aggregate_id (aggregate_id),
+//	  KEY idx_status_created (status, created_at)
+//	);
+//
+// 按用户顺序领取与按保留时长清理所需的索引：
+//
+//	ALTER TABLE notification_outbox ADD KEY idx_sink_user_status (sink, user_uuid, status, id);
+//	ALTER TABLE notification_outbox ADD KEY idx_status_created (status, created_at);
+type OutboxEvent struct {
+	ID            uint64     `gorm:"column:id;primaryKey;autoIncrement"`
+	Sink          string     `gorm:"column:sink"`
+	AggregateID   uint64     `gorm:"column:aggregate_id"`
+	UserUUID      string     `gorm:"column:user_uuid"`
+	EventType     string     `gorm:"column:event_type"`
+	Payload       string     `gorm:"column:payload"`
+	Status        int        `gorm:"column:status"`
+	Attempts      int        `gorm:"column:attempts"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at"`
+	LastError     string     `gorm:"column:last_error"`
+	CreatedAt     time.Time  `gorm:"column:created_at"`
+	SentAt        *time.Time `gorm:"column:sent_at"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "notification_outbox"
+}
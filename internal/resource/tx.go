@@ -0,0 +1,26 @@
+package resource
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+// ContextWithTx returns a context carrying an open gorm transaction so that
+// DAOs invoked with it join the same transaction.
+func ContextWithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// DBFromContext returns the transaction carried by ctx, or fallback when the
+// call is not part of a transaction.
+func DBFromContext(ctx context.Context, fallback *gorm.DB) *gorm.DB {
+	if ctx != nil {
+		if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok && tx != nil {
+			return tx
+		}
+	}
+	return fallback
+}
@@ -17,6 +17,14 @@ type Config struct {
 	Minio           MinioConfig           `mapstructure:"minio"`
 	GRPC            GRPCConfig            `mapstructure:"grpc"`
 	ServiceRegistry ServiceRegistryConfig `mapstructure:"service_registry"`
+	Outbox          OutboxConfig          `mapstructure:"outbox"`
+	SSE             SSEConfig             `mapstructure:"sse"`
+	OpenAPI         OpenAPIConfig         `mapstructure:"open_api"`
+	ProducerAuth    ProducerAuthConfig    `mapstructure:"producer_auth"`
+	RateLimit       RateLimitConfig       `mapstructure:"rate_limit"`
+	Ops             OpsConfig             `mapstructure:"ops"`
+	Tracing         TracingConfig         `mapstructure:"tracing"`
+	Health          HealthConfig          `mapstructure:"health"`
 }
 
 type ServerConfig struct {
@@ -27,6 +35,17 @@ type ServerConfig struct {
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
 }
 
+// IsDev 判断是否为本地/开发环境（mode 为 debug、test、dev 或 local），
+// 只有开发环境允许缺省密钥等不安全的回退配置。
+func (c ServerConfig) IsDev() bool {
+	switch c.Mode {
+	case "debug", "test", "dev", "local":
+		return true
+	default:
+		return false
+	}
+}
+
 type DatabaseConfig struct {
 	Host            string        `mapstructure:"host"`
 	Port            int           `mapstructure:"port"`
@@ -79,10 +98,26 @@ type GRPCConfig struct {
 	Timeout        time.Duration `mapstructure:"timeout"`
 	MaxRecvMsgSize int           `mapstructure:"max_recv_msg_size"`
 	MaxSendMsgSize int           `mapstructure:"max_send_msg_size"`
+	TLS            GRPCTLSConfig `mapstructure:"tls"`
 }
 
+// GRPCTLSConfig gRPC 服务端 TLS；配置 ClientCAFile 后校验客户端证书（mTLS），
+// 证书的 URI SAN / CN 可作为生产方身份。CertFile 为空时使用明文。
+type GRPCTLSConfig struct {
+	CertFile     string `mapstructure:"cert_file"`
+	KeyFile      string `mapstructure:"key_file"`
+	ClientCAFile string `mapstructure:"client_ca_file"`
+}
+
+// ServiceRegistryConfig 把 gRPC 地址注册到 etcd，供其他 go-video 服务通过
+// etcd:///<prefix>/<service_name> 发现。ServiceID 为空时取主机名（Pod 名），
+// RegisterHost 为空时取本机第一个非回环 IPv4 地址。注册带 TTL 租约，
+// 每隔 RefreshInterval 续约，进程退出时注销。
 type ServiceRegistryConfig struct {
 	Enabled         bool          `mapstructure:"enabled"`
+	Endpoints       []string      `mapstructure:"endpoints"`
+	Prefix          string        `mapstructure:"prefix"`
+	DialTimeout     time.Duration `mapstructure:"dial_timeout"`
 	ServiceName     string        `mapstructure:"service_name"`
 	ServiceID       string        `mapstructure:"service_id"`
 	RegisterHost    string        `mapstructure:"register_host"`
@@ -90,12 +125,276 @@ type ServiceRegistryConfig struct {
 	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
 }
 
+// OutboxConfig 事务性 outbox relay 配置。
+type OutboxConfig struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	BatchSize    int           `mapstructure:"batch_size"`
+	// Lease 领取后事件的租约时长，需大于一批事件的投递耗时；relay 在租约
+	// 过半后停止投递本批剩余事件，由租约到期后重新领取。
+	Lease       time.Duration `mapstructure:"lease"`
+	MaxAttempts int           `mapstructure:"max_attempts"`
+	BaseBackoff time.Duration `mapstructure:"base_backoff"`
+	MaxBackoff  time.Duration `mapstructure:"max_backoff"`
+	// Retention 已投递与死信事件的保留时长（默认 7 天），relay 定期分批删除
+	// 更早创建的事件，避免 outbox 表无限增长。
+	Retention time.Duration `mapstructure:"retention"`
+}
+
+// SSEConfig SSE 连接与跨实例分发配置。
+// Hub 选择进程内订阅表实现：sharded（默认，分片读写锁）或 syncmap（无锁读）。
+// Broker 选择实例间消息通道：redis_pubsub（默认）、redis_streams（持久化，
+// 断线重连后按消费组补发）或 nats。
+// redis_streams 下 StreamGroup 为本实例的消费组名，需在同一 pod 重启前后保持不变
+// （如 StatefulSet 的 pod 名）且各实例唯一，默认使用主机名；StreamRetention 为
+// 消息保留时长（默认 1h），超出后不再补发，消费者空闲超过该时长的消费组视为
+// 已下线实例遗留并被清理。签名 max_age 小于 StreamRetention 时按后者校验，
+// 以免补发的消息被当作过期拒绝。
+// TargetedRouting 开启后按 Redis 在线目录只向持有该用户连接的实例投递，
+// 目录不可用时退化为共享频道广播。
+type SSEConfig struct {
+	Hub             string             `mapstructure:"hub"`
+	Broker          string             `mapstructure:"broker"`
+	TargetedRouting bool               `mapstructure:"targeted_routing"`
+	PresenceTTL     time.Duration      `mapstructure:"presence_ttl"`
+	StreamMaxLen    int64              `mapstructure:"stream_max_len"`
+	StreamGroup     string             `mapstructure:"stream_group"`
+	StreamRetention time.Duration      `mapstructure:"stream_retention"`
+	NATS            NATSConfig         `mapstructure:"nats"`
+	Signing         SSESigningConfig   `mapstructure:"signing"`
+	Overflow        SSEOverflowConfig  `mapstructure:"overflow"`
+	Admission       SSEAdmissionConfig `mapstructure:"admission"`
+	Drain           SSEDrainConfig     `mapstructure:"drain"`
+	Stream          SSEStreamConfig    `mapstructure:"stream"`
+	Token           SSETokenConfig     `mapstructure:"token"`
+}
+
+// SSETokenConfig 流令牌配置。/notifications/stream 只接受由
+// /notifications/stream/token 签发的短期令牌（?token=），不再接受 ?user_uuid=。
+// Keys 第一个用于签名，全部用于校验，轮换方式同 signing；未配置时只有开发环境
+// （server.mode 见 IsDev）会为每个进程随机生成密钥、令牌只在签发实例有效，
+// 其他环境启动失败。TTL 为令牌有效期，流在到期前
+// RefreshBefore 下发新令牌（token 事件），客户端重连时使用最新令牌。
+type SSETokenConfig struct {
+	Keys          []SSESigningKey `mapstructure:"keys"`
+	TTL           time.Duration   `mapstructure:"ttl"`
+	RefreshBefore time.Duration   `mapstructure:"refresh_before"`
+}
+
+// SSEStreamConfig 单条 SSE 流的配置；发送缓冲区大小见 overflow.capacity。
+// HeartbeatInterval 心跳注释间隔，需小于网关/代理的空闲超时。
+// Retry 通过 retry: 告知客户端断线后的重连间隔，0 表示使用浏览器默认值。
+// MaxLifetime 流的最长存活时间（减去最多 10% 的随机抖动），到期后发送
+// reconnect 事件让客户端重连，便于连接在实例间重新均衡；0 表示不限制。
+type SSEStreamConfig struct {
+	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"`
+	Retry             time.Duration `mapstructure:"retry"`
+	MaxLifetime       time.Duration `mapstructure:"max_lifetime"`
+}
+
+// SSEDrainConfig 停机时的 SSE 连接排空配置。
+// 收到 SIGTERM 后立即拒绝新连接、/health 与 /readyz 返回 503，等待 PreStopDelay
+// （留给 Kubernetes 摘除 endpoint）后分 Waves 批、每批间隔 WaveInterval
+// 关闭连接，每个客户端收到 retry: 提示（RetryMin~RetryMax 随机抖动）和
+// reconnect 事件。Timeout 为排空总时长上限，超时后剩余连接一次性关闭；
+// PreStopDelay+Timeout 应小于 terminationGracePeriodSeconds。
+type SSEDrainConfig struct {
+	PreStopDelay time.Duration `mapstructure:"pre_stop_delay"`
+	Timeout      time.Duration `mapstructure:"timeout"`
+	Waves        int           `mapstructure:"waves"`
+	WaveInterval time.Duration `mapstructure:"wave_interval"`
+	RetryMin     time.Duration `mapstructure:"retry_min"`
+	RetryMax     time.Duration `mapstructure:"retry_max"`
+}
+
+// SSEAdmissionConfig 流连接准入控制，0 表示不限制。
+// 超过 MaxPerInstance 返回 503；超过 MaxPerUser 时 OnUserLimit=evict_oldest
+// （默认）断开该用户最早的连接，reject 则返回 429。被断开的连接最后收到
+// evicted 事件（retry 为 1h），客户端收到后须关闭 EventSource、不再自动重连。
+// ReconnectRate（每秒）/ReconnectBurst 限制单用户新建连接的速度，防止重连风暴。
+// 被拒绝的请求带 Retry-After=RetryAfter。
+type SSEAdmissionConfig struct {
+	MaxPerInstance int           `mapstructure:"max_per_instance"`
+	MaxPerUser     int           `mapstructure:"max_per_user"`
+	OnUserLimit    string        `mapstructure:"on_user_limit"`
+	ReconnectRate  float64       `mapstructure:"reconnect_rate"`
+	ReconnectBurst int           `mapstructure:"reconnect_burst"`
+	RetryAfter     time.Duration `mapstructure:"retry_after"`
+}
+
+// SSEOverflowConfig 单个 SSE 连接的发送队列配置。
+// 状态类事件（未读数）在队列中按类型合并，只保留最新一条；队列满时
+// Action=resync（默认）丢弃积压并下发一条 resync 事件让客户端重新拉取，
+// Action=drop 丢弃新事件。DisconnectAfter 内一直未消费的连接再次溢出时断开，
+// 0 表示不断开。
+type SSEOverflowConfig struct {
+	Capacity        int           `mapstructure:"capacity"`
+	Action          string        `mapstructure:"action"`
+	DisconnectAfter time.Duration `mapstructure:"disconnect_after"`
+}
+
+// SSESigningConfig 实例间消息 HMAC 签名配置。
+// Keys 第一个用于签名，全部用于校验；未配置 key 时不签名。
+// 轮换：先在所有实例追加新 key，再把它移到第一个，最后删除旧 key。
+type SSESigningConfig struct {
+	Keys          []SSESigningKey `mapstructure:"keys"`
+	MaxAge        time.Duration   `mapstructure:"max_age"`
+	AllowUnsigned bool            `mapstructure:"allow_unsigned"`
+}
+
+// SSESigningKey 共享密钥。
+type SSESigningKey struct {
+	ID     string `mapstructure:"id"`
+	Secret string `mapstructure:"secret"`
+}
+
+// ProducerAuthConfig 通知生产方鉴权。开启后 POST /notifications 与 gRPC
+// CreateNotification 只接受登记过的生产方：HTTP 使用 X-Api-Key 或 HMAC 签名
+// （X-Producer/X-Timestamp/X-Nonce/X-Signature，MaxSkew 为时间戳允许偏差；
+// 每个 X-Nonce 只能使用一次，记录在 Redis 中，Redis 不可用时签名请求被拒绝），
+// gRPC 使用 x-api-key metadata 或 mTLS 证书身份。每个生产方只能发送 Types
+// 中的通知类型，"*" 表示不限。
+type ProducerAuthConfig struct {
+	Enabled   bool             `mapstructure:"enabled"`
+	MaxSkew   time.Duration    `mapstructure:"max_skew"`
+	Producers []ProducerConfig `mapstructure:"producers"`
+}
+
+// ProducerConfig 单个生产方的凭证与可发送的通知类型。
+type ProducerConfig struct {
+	Name        string   `mapstructure:"name"`
+	APIKeys     []string `mapstructure:"api_keys"`
+	HMACSecrets []string `mapstructure:"hmac_secrets"`
+	Identities  []string `mapstructure:"identities"`
+	Types       []string `mapstructure:"types"`
+}
+
+// RateLimitConfig 通知创建频控，基于 Redis 滑动窗口，多实例共享计数。
+// 只有成功创建的通知计入配额，写库失败会退还。Redis 不可用时放行。
+type RateLimitConfig struct {
+	Enabled bool            `mapstructure:"enabled"`
+	Rules   []RateLimitRule `mapstructure:"rules"`
+}
+
+// RateLimitRule 一条频控规则：Window 内最多 Limit 条。
+// Scope：producer（按生产方，未鉴权的调用不计）、recipient（按接收人）、
+// recipient_type（按接收人+通知类型）；Type/Producer 非空时只作用于该类型/生产方。
+// Action：reject（返回 429 并带 Retry-After，gRPC 为 ResourceExhausted 并带 RetryInfo）、drop（静默丢弃）、no_push（落库但不实时推送）。
+// 同时超出多条规则时取最严格的处理。
+type RateLimitRule struct {
+	Name     string        `mapstructure:"name"`
+	Scope    string        `mapstructure:"scope"`
+	Type     string        `mapstructure:"type"`
+	Producer string        `mapstructure:"producer"`
+	Limit    int           `mapstructure:"limit"`
+	Window   time.Duration `mapstructure:"window"`
+	Action   string        `mapstructure:"action"`
+}
+
+// OpsConfig 运维接口（/ops）与排障接口（/debug）凭证，与用户、生产方凭证相互独立。每个操作人
+// 一个 token（Authorization: Bearer <token>），写操作的审计记录带上操作人；
+// 未配置操作人时运维接口全部拒绝。
+type OpsConfig struct {
+	Operators []OpsOperator `mapstructure:"operators"`
+}
+
+// OpsOperator 运维操作人及其凭证。
+type OpsOperator struct {
+	Name  string `mapstructure:"name"`
+	Token string `mapstructure:"token"`
+}
+
+// TracingConfig OpenTelemetry 链路追踪。Exporter：stdout（本地调试，span 打印到标准输出）、
+// otlp（OTLP/gRPC 发送到 Endpoint 的 collector）。SampleRatio 只作用于没有上游
+// trace context 的请求，其余沿用上游的采样决定。关闭时仍透传上游的 trace context，
+// 日志中也会带上上游的 trace_id。
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Exporter    string  `mapstructure:"exporter"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+	ServiceName string  `mapstructure:"service_name"`
+}
+
+// HealthConfig 就绪检查（/readyz 与 gRPC health 服务）。依赖检查项：db、redis、bridge、
+// grpc，每项超时为 Timeout；Optional 中列出的检查失败时只标记 degraded，不影响就绪，
+// 未配置时默认为 redis 与 bridge（Redis 故障时 SSE 仍可本机投递，outbox 会重试）。
+// gRPC health 状态每隔 Interval 按检查结果刷新。
+type HealthConfig struct {
+	Timeout  time.Duration `mapstructure:"timeout"`
+	Interval time.Duration `mapstructure:"interval"`
+	Optional []string      `mapstructure:"optional"`
+}
+
+// OpenAPIConfig 面向浏览器直连的开放接口（/api/notification/v1/open），
+// 默认关闭；开启后列表、已读和流令牌接口要求 JWT，流接口使用流令牌。
+type OpenAPIConfig struct {
+	Enabled bool      `mapstructure:"enabled"`
+	JWT     JWTConfig `mapstructure:"jwt"`
+}
+
+// JWTConfig JWT 校验配置。Keys（HMAC secret 或 PEM 公钥）与 JWKSFile 至少配置一项，
+// JWKS 文件变更后自动重新加载。Issuer/Audience 非空时强制校验；
+// UserClaim 指定用户 UUID 所在的 claim，支持 "ext.user_uuid" 形式的嵌套路径，默认 sub。
+type JWTConfig struct {
+	Issuer     string        `mapstructure:"issuer"`
+	Audience   string        `mapstructure:"audience"`
+	UserClaim  string        `mapstructure:"user_claim"`
+	Algorithms []string      `mapstructure:"algorithms"`
+	Leeway     time.Duration `mapstructure:"leeway"`
+	Keys       []JWTKey      `mapstructure:"keys"`
+	JWKSFile   string        `mapstructure:"jwks_file"`
+}
+
+// JWTKey 静态 JWT 校验密钥，Secret 与 PublicKey 二选一。
+type JWTKey struct {
+	ID        string `mapstructure:"id"`
+	Secret    string `mapstructure:"secret"`
+	PublicKey string `mapstructure:"public_key"`
+}
+
+// NATSConfig NATS 连接配置（sse.broker=nats 时使用）。
+type NATSConfig struct {
+	URL  string `mapstructure:"url"`
+	Name string `mapstructure:"name"`
+}
+
 // KafkaConfig Kafka配置
 type KafkaConfig struct {
-	BootstrapServers []string `mapstructure:"bootstrap_servers"`
-	ClientID         string   `mapstructure:"client_id"`
-	GroupID          string   `mapstructure:"group_id"`
-	Enabled          bool     `mapstructure:"enabled"`
+	BootstrapServers []string            `mapstructure:"bootstrap_servers"`
+	ClientID         string              `mapstructure:"client_id"`
+	GroupID          string              `mapstructure:"group_id"`
+	Enabled          bool                `mapstructure:"enabled"`
+	Consumer         KafkaConsumerConfig `mapstructure:"consumer"`
+	Producer         KafkaProducerConfig `mapstructure:"producer"`
+}
+
+// KafkaProducerConfig 通知生命周期事件（created/read/deleted）发布配置。
+type KafkaProducerConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Topic   string `mapstructure:"topic"`
+}
+
+// KafkaConsumerConfig 领域事件消费配置：按 topic + event_type 路由到通知创建。
+type KafkaConsumerConfig struct {
+	// DeadLetterTopic 只接收永远无法处理的消息（解析失败、字段缺失、校验不通过）。
+	DeadLetterTopic string `mapstructure:"dead_letter_topic"`
+	// RetryBackoff 为临时错误（如数据库不可用）的首次重试间隔，之后逐次翻倍，
+	// 不超过 MaxRetryBackoff。临时错误会一直重试且不提交 offset，直到成功或服务停止。
+	RetryBackoff    time.Duration      `mapstructure:"retry_backoff"`
+	MaxRetryBackoff time.Duration      `mapstructure:"max_retry_backoff"`
+	Routes          []KafkaRouteConfig `mapstructure:"routes"`
+}
+
+// KafkaRouteConfig 描述一种领域事件如何映射为一条通知。
+// Title/Content 为 text/template 模板，数据为事件 payload。
+type KafkaRouteConfig struct {
+	Topic            string `mapstructure:"topic"`
+	EventType        string `mapstructure:"event_type"`
+	NotificationType string `mapstructure:"notification_type"`
+	RecipientField   string `mapstructure:"recipient_field"`
+	Title            string `mapstructure:"title"`
+	Content          string `mapstructure:"content"`
 }
 
 // Load 加载配置文件。
@@ -111,6 +410,19 @@ func Load(configPath string) (*Config, error) {
 	viper.SetDefault("kafka.client_id", "notification-service")
 	viper.SetDefault("kafka.group_id", "notification-service-group")
 	viper.SetDefault("kafka.bootstrap_servers", []string{"localhost:29092"})
+	viper.SetDefault("kafka.producer.topic", "notification-service.lifecycle")
+
+	// SSE 默认按在线目录定向投递
+	viper.SetDefault("sse.targeted_routing", true)
+	viper.SetDefault("sse.hub", "sharded")
+	viper.SetDefault("sse.broker", "redis_pubsub")
+	viper.SetDefault("sse.nats.url", "nats://127.0.0.1:4222")
+	viper.SetDefault("sse.nats.name", "notification-service")
+	viper.SetDefault("sse.overflow.disconnect_after", time.Minute)
+	viper.SetDefault("sse.admission.max_per_user", 10)
+	viper.SetDefault("sse.stream.retry", 3*time.Second)
+	viper.SetDefault("sse.admission.reconnect_rate", 0.5)
+	viper.SetDefault("sse.admission.reconnect_burst", 10)
 
 	// 设置环境变量前缀
 	viper.SetEnvPrefix("GO_VIDEO")
@@ -133,8 +445,119 @@ func normalize(c *Config) {
 	if c.ServiceRegistry.TTL == 0 {
 		c.ServiceRegistry.TTL = 30 * time.Second
 	}
-	if c.ServiceRegistry.RefreshInterval == 0 {
-		c.ServiceRegistry.RefreshInterval = 10 * time.Second
+	if c.ServiceRegistry.RefreshInterval == 0 || c.ServiceRegistry.RefreshInterval >= c.ServiceRegistry.TTL {
+		c.ServiceRegistry.RefreshInterval = c.ServiceRegistry.TTL / 3
+	}
+	if c.ServiceRegistry.Prefix == "" {
+		c.ServiceRegistry.Prefix = "go-video/services"
+	}
+	if c.ServiceRegistry.DialTimeout <= 0 {
+		c.ServiceRegistry.DialTimeout = 5 * time.Second
+	}
+	if c.ServiceRegistry.ServiceName == "" {
+		c.ServiceRegistry.ServiceName = "notification-service"
+	}
+	if c.Kafka.Consumer.RetryBackoff <= 0 {
+		c.Kafka.Consumer.RetryBackoff = time.Second
+	}
+	if c.Kafka.Consumer.MaxRetryBackoff < c.Kafka.Consumer.RetryBackoff {
+		c.Kafka.Consumer.MaxRetryBackoff = max(30*time.Second, c.Kafka.Consumer.RetryBackoff)
+	}
+	if c.SSE.Broker == "" {
+		c.SSE.Broker = "redis_pubsub"
+	}
+	if c.SSE.StreamMaxLen <= 0 {
+		c.SSE.StreamMaxLen = 10000
+	}
+	if c.SSE.StreamRetention <= 0 {
+		c.SSE.StreamRetention = time.Hour
+	}
+	if c.SSE.Signing.MaxAge <= 0 {
+		c.SSE.Signing.MaxAge = 2 * time.Minute
+	}
+	if c.SSE.PresenceTTL <= 0 {
+		c.SSE.PresenceTTL = 30 * time.Second
+	}
+	if c.SSE.Overflow.Capacity <= 0 {
+		c.SSE.Overflow.Capacity = 16
+	}
+	if c.SSE.Overflow.Action == "" {
+		c.SSE.Overflow.Action = "resync"
+	}
+	if c.SSE.Admission.OnUserLimit == "" {
+		c.SSE.Admission.OnUserLimit = "evict_oldest"
+	}
+	if c.SSE.Admission.RetryAfter <= 0 {
+		c.SSE.Admission.RetryAfter = 5 * time.Second
+	}
+	if c.SSE.Stream.HeartbeatInterval <= 0 {
+		c.SSE.Stream.HeartbeatInterval = 25 * time.Second
+	}
+	if c.ProducerAuth.MaxSkew <= 0 {
+		c.ProducerAuth.MaxSkew = 5 * time.Minute
+	}
+	if c.OpenAPI.JWT.UserClaim == "" {
+		c.OpenAPI.JWT.UserClaim = "sub"
+	}
+	if c.SSE.Token.TTL <= 0 {
+		c.SSE.Token.TTL = 5 * time.Minute
+	}
+	if c.SSE.Token.RefreshBefore <= 0 || c.SSE.Token.RefreshBefore >= c.SSE.Token.TTL {
+		c.SSE.Token.RefreshBefore = c.SSE.Token.TTL / 5
+	}
+	if c.SSE.Drain.Timeout <= 0 {
+		c.SSE.Drain.Timeout = 20 * time.Second
+	}
+	if c.SSE.Drain.Waves <= 0 {
+		c.SSE.Drain.Waves = 5
+	}
+	if c.SSE.Drain.WaveInterval <= 0 {
+		c.SSE.Drain.WaveInterval = 2 * time.Second
+	}
+	if c.SSE.Drain.RetryMin <= 0 {
+		c.SSE.Drain.RetryMin = time.Second
+	}
+	if c.SSE.Drain.RetryMax < c.SSE.Drain.RetryMin {
+		c.SSE.Drain.RetryMax = c.SSE.Drain.RetryMin + 9*time.Second
+	}
+	if c.Outbox.PollInterval <= 0 {
+		c.Outbox.PollInterval = time.Second
+	}
+	if c.Outbox.BatchSize <= 0 {
+		c.Outbox.BatchSize = 100
+	}
+	if c.Outbox.Lease <= 0 {
+		c.Outbox.Lease = time.Minute
+	}
+	if c.Outbox.MaxAttempts <= 0 {
+		c.Outbox.MaxAttempts = 10
+	}
+	if c.Outbox.BaseBackoff <= 0 {
+		c.Outbox.BaseBackoff = time.Second
+	}
+	if c.Outbox.MaxBackoff <= 0 {
+		c.Outbox.MaxBackoff = 5 * time.Minute
+	}
+	if c.Outbox.Retention <= 0 {
+		c.Outbox.Retention = 7 * 24 * time.Hour
+	}
+	if c.Tracing.Exporter == "" {
+		c.Tracing.Exporter = "stdout"
+	}
+	if c.Tracing.SampleRatio <= 0 || c.Tracing.SampleRatio > 1 {
+		c.Tracing.SampleRatio = 1
+	}
+	if c.Tracing.ServiceName == "" {
+		c.Tracing.ServiceName = "notification-service"
+	}
+	if c.Health.Timeout <= 0 {
+		c.Health.Timeout = 2 * time.Second
+	}
+	if c.Health.Interval <= 0 {
+		c.Health.Interval = 5 * time.Second
+	}
+	if c.Health.Optional == nil {
+		c.Health.Optional = []string{"redis", "bridge"}
 	}
 }
 
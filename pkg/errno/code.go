@@ -16,9 +16,13 @@ var (
 
 	ErrParameterInvalid = &Errno{Code: 400, Message: "Invalid parameter %s"}
 	ErrUnauthorized     = &Errno{Code: 401, Message: "Unauthorized"}
+	ErrForbidden        = &Errno{Code: 403, Message: "Forbidden %s"}
 	ErrNotFound         = &Errno{Code: 404, Message: "Not found"}
+	ErrConflict         = &Errno{Code: 409, Message: "Conflict %s"}
+	ErrTooManyRequests  = &Errno{Code: 429, Message: "Too many requests"}
 
-	ErrInternalServer = &Errno{Code: 500, Message: "Internal server error"}
-	ErrDatabase       = &Errno{Code: 501, Message: "Database error"}
-	ErrUnknown        = &Errno{Code: 510, Message: "Unknown error"}
+	ErrInternalServer     = &Errno{Code: 500, Message: "Internal server error"}
+	ErrDatabase           = &Errno{Code: 501, Message: "Database error"}
+	ErrServiceUnavailable = &Errno{Code: 503, Message: "Service unavailable"}
+	ErrUnknown            = &Errno{Code: 510, Message: "Unknown error"}
 )
@@ -31,6 +31,11 @@ func (e BizError) Error() string {
 	return fmt.Sprintf("{\"OriginError\": \"%v\", \"Message\": \"%s\", \"Caller\": \"%s\", \"CallStack\": \"%s\"}", e.originError, e.Message(), e.caller, e.callStack)
 }
 
+// Unwrap 返回原始错误，便于 errors.As 取出附带的信息（如频控的重试时间）。
+func (e BizError) Unwrap() error {
+	return e.originError
+}
+
 func (e BizError) IsSuccess() bool {
 	return e.code == OK.Code
 }
@@ -0,0 +1,20 @@
+package grpcutil
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"notification-service/pkg/metrics"
+)
+
+// UnaryServerMetricsInterceptor records request counts and latency per
+// method and status code.
+func UnaryServerMetricsInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	metrics.ObserveGRPCRequest(info.FullMethod, status.Code(err).String(), time.Since(start))
+	return resp, err
+}
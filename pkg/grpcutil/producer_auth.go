@@ -0,0 +1,70 @@
+package grpcutil
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"notification-service/pkg/logger"
+	"notification-service/pkg/producerauth"
+)
+
+// APIKeyMetadataKey carries a producer API key on gRPC calls.
+const APIKeyMetadataKey = "x-api-key"
+
+// UnaryServerProducerAuthInterceptor authenticates the calling producer of
+// the given full methods from the x-api-key metadata or, failing that, the
+// verified mTLS client certificate, and stores it in the context for the
+// application layer. Other methods, e.g. grpc.health.v1.Health/Check, pass
+// through unauthenticated.
+func UnaryServerProducerAuthInterceptor(reg *producerauth.Registry, methods ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !slices.Contains(methods, info.FullMethod) {
+			return handler(ctx, req)
+		}
+		p, err := authenticateProducer(ctx, reg)
+		if err != nil {
+			logger.WithContext(ctx).Warnf("producer auth rejected method=%s error=%v", info.FullMethod, err)
+			return nil, status.Error(codes.Unauthenticated, "producer authentication failed")
+		}
+		return handler(producerauth.NewContext(ctx, p), req)
+	}
+}
+
+func authenticateProducer(ctx context.Context, reg *producerauth.Registry) (*producerauth.Producer, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if keys := md.Get(APIKeyMetadataKey); len(keys) > 0 && keys[0] != "" {
+			return reg.AuthenticateAPIKey(keys[0])
+		}
+	}
+	return reg.AuthenticateIdentity(peerIdentities(ctx)...)
+}
+
+// peerIdentities returns the URI SANs (e.g. SPIFFE IDs) and common name of
+// the verified client certificate, if the call came over mTLS.
+func peerIdentities(ctx context.Context) []string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	leaf := tlsInfo.State.VerifiedChains[0][0]
+	ids := make([]string, 0, len(leaf.URIs)+1)
+	for _, u := range leaf.URIs {
+		ids = append(ids, u.String())
+	}
+	if cn := strings.TrimSpace(leaf.Subject.CommonName); cn != "" {
+		ids = append(ids, cn)
+	}
+	return ids
+}
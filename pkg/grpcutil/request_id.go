@@ -58,9 +58,9 @@ func UnaryClientRequestIDInterceptor(ctx context.Context, method string, req int
 	}
 	if err != nil {
 		fields["error"] = err.Error()
-		logger.WithFields(fields).Warn("grpc client call failed")
+		logger.WithContext(ctx).WithFields(fields).Warn("grpc client call failed")
 	} else {
-		logger.WithFields(fields).Info("grpc client call")
+		logger.WithContext(ctx).WithFields(fields).Info("grpc client call")
 	}
 	return err
 }
@@ -79,9 +79,9 @@ func UnaryServerRequestIDInterceptor(ctx context.Context, req interface{}, info
 	}
 	if err != nil {
 		fields["error"] = err.Error()
-		logger.WithFields(fields).Warn("grpc server call failed")
+		logger.WithContext(ctx).WithFields(fields).Warn("grpc server call failed")
 	} else {
-		logger.WithFields(fields).Info("grpc server call")
+		logger.WithContext(ctx).WithFields(fields).Info("grpc server call")
 	}
 	return resp, err
 }
@@ -0,0 +1,102 @@
+package grpcutil
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"notification-service/pkg/errno"
+	"notification-service/pkg/ratelimit"
+)
+
+const (
+	// errorInfoDomain identifies this service in google.rpc.ErrorInfo details.
+	errorInfoDomain = "notification-service"
+	// errorInfoReason is the ErrorInfo reason used for all business errors.
+	errorInfoReason = "BIZ_ERROR"
+	// BizCodeMetadataKey is the ErrorInfo metadata key carrying the errno code.
+	BizCodeMetadataKey = "biz_code"
+)
+
+// CodeFromBizCode maps an errno code to the closest gRPC status code.
+func CodeFromBizCode(bizCode int) codes.Code {
+	switch bizCode {
+	case errno.OK.Code:
+		return codes.OK
+	case errno.ErrParameterInvalid.Code:
+		return codes.InvalidArgument
+	case errno.ErrUnauthorized.Code:
+		return codes.Unauthenticated
+	case errno.ErrForbidden.Code:
+		return codes.PermissionDenied
+	case errno.ErrTooManyRequests.Code:
+		return codes.ResourceExhausted
+	case errno.ErrNotFound.Code:
+		return codes.NotFound
+	case errno.ErrConflict.Code:
+		return codes.AlreadyExists
+	case errno.ErrDatabase.Code, errno.ErrServiceUnavailable.Code:
+		return codes.Unavailable
+	case errno.ErrInternalServer.Code:
+		return codes.Internal
+	default:
+		return codes.Unknown
+	}
+}
+
+// StatusError converts an application error into a gRPC status error.
+// errno.Errno and errno.BizError values are mapped by their code and carry
+// a google.rpc.ErrorInfo detail with the original biz code, plus a
+// google.rpc.RetryInfo detail when a rate limit says when to retry; context errors
+// map to Canceled/DeadlineExceeded; anything else becomes Internal.
+func StatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	bizCode, message := errno.ErrInternalServer.Code, errno.ErrInternalServer.Message
+	var (
+		bizErr errno.BizError
+		no     *errno.Errno
+	)
+	switch {
+	case errors.As(err, &bizErr):
+		bizCode, message = bizErr.Code(), bizErr.Message()
+	case errors.As(err, &no):
+		bizCode, message = no.Code, no.Message
+	}
+
+	st := status.New(CodeFromBizCode(bizCode), message)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason: errorInfoReason,
+		Domain: errorInfoDomain,
+		Metadata: map[string]string{
+			BizCodeMetadataKey: strconv.Itoa(bizCode),
+		},
+	}}
+	var rejected *ratelimit.RejectedError
+	if errors.As(err, &rejected) && rejected.RetryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(rejected.RetryAfter)})
+	}
+	withDetails, detailErr := st.WithDetails(details...)
+	if detailErr != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
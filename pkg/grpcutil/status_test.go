@@ -0,0 +1,72 @@
+package grpcutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"notification-service/pkg/errno"
+	"notification-service/pkg/ratelimit"
+)
+
+func TestStatusError(t *testing.T) {
+	cases := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		bizCode string
+	}{
+		{"errno", errno.ErrParameterInvalid, codes.InvalidArgument, "400"},
+		{"biz error", errno.NewBizError(errno.ErrDatabase, errors.New("conn refused")), codes.Unavailable, "501"},
+		{"unauthorized", errno.ErrUnauthorized, codes.Unauthenticated, "401"},
+		{"forbidden", errno.NewSimpleBizError(errno.ErrForbidden, nil, "type"), codes.PermissionDenied, "403"},
+		{"plain error", errors.New("boom"), codes.Internal, "500"},
+		{"deadline", context.DeadlineExceeded, codes.DeadlineExceeded, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			st, ok := status.FromError(StatusError(tc.err))
+			if !ok {
+				t.Fatalf("expected status error")
+			}
+			if st.Code() != tc.code {
+				t.Fatalf("code = %s, want %s", st.Code(), tc.code)
+			}
+			var got string
+			for _, d := range st.Details() {
+				if info, ok := d.(*errdetails.ErrorInfo); ok {
+					got = info.GetMetadata()[BizCodeMetadataKey]
+				}
+			}
+			if got != tc.bizCode {
+				t.Fatalf("biz_code = %q, want %q", got, tc.bizCode)
+			}
+		})
+	}
+
+	if StatusError(nil) != nil {
+		t.Fatalf("nil error should stay nil")
+	}
+}
+
+func TestStatusErrorCarriesRetryInfo(t *testing.T) {
+	err := errno.NewSimpleBizError(errno.ErrTooManyRequests, &ratelimit.RejectedError{Rule: "producer", RetryAfter: 1500 * time.Millisecond})
+	st, _ := status.FromError(StatusError(err))
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("code = %s", st.Code())
+	}
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			if got := info.GetRetryDelay().AsDuration(); got != 1500*time.Millisecond {
+				t.Fatalf("retry delay = %s", got)
+			}
+			return
+		}
+	}
+	t.Fatalf("RetryInfo detail missing: %v", st.Details())
+}
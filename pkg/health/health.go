@@ -0,0 +1,136 @@
+// Package health runs the dependency checks behind the readiness probes.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Status is the overall readiness outcome.
+type Status string
+
+const (
+	// StatusOK means every check passed.
+	StatusOK Status = "ok"
+	// StatusDegraded means only optional checks failed; the instance stays
+	// ready.
+	StatusDegraded Status = "degraded"
+	// StatusUnavailable means a required check failed.
+	StatusUnavailable Status = "unavailable"
+)
+
+// CheckFunc reports whether a dependency is usable.
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the outcome of one check.
+type CheckResult struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	Optional  bool   `json:"optional,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// Report is the outcome of all checks.
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Ready reports whether the instance should receive traffic.
+func (r Report) Ready() bool {
+	return r.Status != StatusUnavailable
+}
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	optional bool
+}
+
+// Checker holds the registered checks and runs them concurrently, each
+// bounded by the checker's timeout.
+type Checker struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []check
+}
+
+// NewChecker returns a Checker that gives each check at most timeout.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Register adds a check. A failing optional check degrades the report
+// without making the instance unready.
+func (c *Checker) Register(name string, fn CheckFunc, optional bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check{name: name, fn: fn, optional: optional})
+}
+
+// Run runs every check and aggregates the results.
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]check(nil), c.checks...)
+	c.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, chk := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, chk)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks))}
+	for i, chk := range checks {
+		res := results[i]
+		report.Checks[chk.name] = res
+		if res.Status == "ok" {
+			continue
+		}
+		if !chk.optional {
+			report.Status = StatusUnavailable
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, chk check) CheckResult {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	err := safeCall(ctx, chk.fn)
+	res := CheckResult{
+		Status:    "ok",
+		Optional:  chk.optional,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		res.Status = "failed"
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// safeCall turns a panicking check into a failure instead of crashing the
+// probe handler.
+func safeCall(ctx context.Context, fn CheckFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("check panicked")
+		}
+	}()
+	return fn(ctx)
+}
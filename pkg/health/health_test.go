@@ -0,0 +1,54 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckerAggregatesResults(t *testing.T) {
+	fail := func(context.Context) error { return errors.New("down") }
+	ok := func(context.Context) error { return nil }
+
+	c := NewChecker(time.Second)
+	c.Register("db", ok, false)
+	if r := c.Run(context.Background()); r.Status != StatusOK || !r.Ready() {
+		t.Fatalf("all ok: %+v", r)
+	}
+
+	c.Register("bridge", fail, true)
+	r := c.Run(context.Background())
+	if r.Status != StatusDegraded || !r.Ready() {
+		t.Fatalf("optional failure should degrade: %+v", r)
+	}
+	if res := r.Checks["bridge"]; res.Status != "failed" || res.Error != "down" || !res.Optional {
+		t.Fatalf("unexpected bridge result %+v", res)
+	}
+
+	c.Register("redis", fail, false)
+	if r := c.Run(context.Background()); r.Status != StatusUnavailable || r.Ready() {
+		t.Fatalf("required failure should make the instance unready: %+v", r)
+	}
+}
+
+func TestCheckerTimesOutSlowChecks(t *testing.T) {
+	c := NewChecker(20 * time.Millisecond)
+	c.Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, false)
+	c.Register("panics", func(context.Context) error { panic("boom") }, true)
+
+	start := time.Now()
+	r := c.Run(context.Background())
+	if time.Since(start) > time.Second {
+		t.Fatalf("run was not bounded by the timeout")
+	}
+	if r.Status != StatusUnavailable || r.Checks["slow"].Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("unexpected report %+v", r)
+	}
+	if r.Checks["panics"].Status != "failed" {
+		t.Fatalf("panicking check should fail: %+v", r.Checks["panics"])
+	}
+}
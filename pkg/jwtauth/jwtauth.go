@@ -0,0 +1,200 @@
+// Package jwtauth verifies end-user JWTs for the public Open API, with
+// static keys and/or a JWKS file that is reloaded when it changes.
+package jwtauth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"notification-service/pkg/logger"
+)
+
+// ErrNoUser is returned for valid tokens without the configured user claim.
+var ErrNoUser = errors.New("jwtauth: token has no user claim")
+
+// defaultAlgorithms are accepted when Config.Algorithms is empty. Each key
+// only verifies the algorithms of its type, so an HMAC secret can never be
+// confused with a public key.
+var defaultAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA", "HS256", "HS384", "HS512"}
+
+// jwksCheckInterval bounds how often the JWKS file is stat'ed for changes.
+const jwksCheckInterval = 10 * time.Second
+
+// StaticKey is a key from configuration: an HMAC Secret or a PEM-encoded
+// PublicKey.
+type StaticKey struct {
+	ID        string
+	Secret    string
+	PublicKey string
+}
+
+// Config configures a Verifier.
+type Config struct {
+	// Issuer and Audience are required claims when set.
+	Issuer   string
+	Audience string
+	// UserClaim names the claim holding the user UUID; dots address nested
+	// claims, e.g. "ext.user_uuid". Defaults to "sub".
+	UserClaim  string
+	Algorithms []string
+	Leeway     time.Duration
+	Keys       []StaticKey
+	JWKSFile   string
+}
+
+// keySet maps key IDs to verification keys; unnamed keys are tried in turn
+// for tokens without a kid.
+type keySet struct {
+	byID    map[string]jwt.VerificationKey
+	unnamed []jwt.VerificationKey
+}
+
+func (ks *keySet) add(kid string, key jwt.VerificationKey) {
+	if kid == "" {
+		ks.unnamed = append(ks.unnamed, key)
+		return
+	}
+	ks.byID[kid] = key
+}
+
+// Verifier validates tokens and maps them to users.
+type Verifier struct {
+	cfg    Config
+	parser *jwt.Parser
+	static keySet
+
+	mu        sync.Mutex
+	jwks      keySet
+	jwksMod   time.Time
+	lastCheck time.Time
+	now       func() time.Time
+}
+
+// NewVerifier loads the configured keys.
+func NewVerifier(cfg Config) (*Verifier, error) {
+	if cfg.UserClaim == "" {
+		cfg.UserClaim = "sub"
+	}
+	if len(cfg.Algorithms) == 0 {
+		cfg.Algorithms = defaultAlgorithms
+	}
+	if len(cfg.Keys) == 0 && cfg.JWKSFile == "" {
+		return nil, errors.New("jwtauth: no keys or jwks file configured")
+	}
+
+	v := &Verifier{cfg: cfg, static: keySet{byID: map[string]jwt.VerificationKey{}}, now: time.Now}
+	for i, k := range cfg.Keys {
+		key, err := parseStaticKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("jwtauth: key %d (%s): %w", i, k.ID, err)
+		}
+		v.static.add(k.ID, key)
+	}
+	if cfg.JWKSFile != "" {
+		if err := v.loadJWKS(); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(cfg.Algorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+		jwt.WithTimeFunc(func() time.Time { return v.now() }),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	v.parser = jwt.NewParser(opts...)
+	return v, nil
+}
+
+// Verify validates token and returns the user UUID from the user claim.
+func (v *Verifier) Verify(token string) (string, error) {
+	claims := jwt.MapClaims{}
+	if _, err := v.parser.ParseWithClaims(token, claims, v.keyFunc); err != nil {
+		return "", err
+	}
+	var cur interface{} = map[string]interface{}(claims)
+	for _, part := range strings.Split(v.cfg.UserClaim, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return "", ErrNoUser
+		}
+		cur = m[part]
+	}
+	user, ok := cur.(string)
+	if !ok || user == "" {
+		return "", ErrNoUser
+	}
+	return user, nil
+}
+
+func (v *Verifier) keyFunc(t *jwt.Token) (interface{}, error) {
+	jwks := v.currentJWKS()
+	if kid, _ := t.Header["kid"].(string); kid != "" {
+		if key, ok := v.static.byID[kid]; ok {
+			return key, nil
+		}
+		if key, ok := jwks.byID[kid]; ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("jwtauth: unknown kid %q", kid)
+	}
+	keys := append(append([]jwt.VerificationKey(nil), v.static.unnamed...), jwks.unnamed...)
+	if len(keys) == 0 {
+		return nil, errors.New("jwtauth: token has no kid")
+	}
+	return jwt.VerificationKeySet{Keys: keys}, nil
+}
+
+// currentJWKS returns the JWKS keys, reloading the file if it changed. A
+// file that fails to load keeps the previous keys.
+func (v *Verifier) currentJWKS() keySet {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.cfg.JWKSFile == "" || v.now().Sub(v.lastCheck) < jwksCheckInterval {
+		return v.jwks
+	}
+	v.lastCheck = v.now()
+	if st, err := os.Stat(v.cfg.JWKSFile); err == nil && !st.ModTime().Equal(v.jwksMod) {
+		if err := v.loadJWKSLocked(); err != nil {
+			logger.Errorf("jwtauth: reload jwks failed file=%s error=%v", v.cfg.JWKSFile, err)
+		}
+	}
+	return v.jwks
+}
+
+func (v *Verifier) loadJWKS() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.loadJWKSLocked()
+}
+
+func (v *Verifier) loadJWKSLocked() error {
+	st, err := os.Stat(v.cfg.JWKSFile)
+	if err != nil {
+		return fmt.Errorf("jwtauth: jwks file: %w", err)
+	}
+	data, err := os.ReadFile(v.cfg.JWKSFile)
+	if err != nil {
+		return fmt.Errorf("jwtauth: jwks file: %w", err)
+	}
+	ks, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("jwtauth: jwks file %s: %w", v.cfg.JWKSFile, err)
+	}
+	v.jwks = ks
+	v.jwksMod = st.ModTime()
+	v.lastCheck = v.now()
+	return nil
+}
@@ -0,0 +1,141 @@
+package jwtauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writeJWKS(t *testing.T, path string, keys ...map[string]string) {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func rsaJWK(kid string, k *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"n":   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, k *ecdsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestVerifier(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksFile, rsaJWK("rsa-1", rsaKey))
+
+	v, err := NewVerifier(Config{
+		Issuer:    "https://auth.go-video",
+		Audience:  "notification",
+		UserClaim: "ext.user_uuid",
+		Keys:      []StaticKey{{ID: "hs", Secret: "shared-secret"}},
+		JWKSFile:  jwksFile,
+	})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	v.now = func() time.Time { return now }
+	v.lastCheck = now
+
+	claims := func(mod func(jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"iss": "https://auth.go-video",
+			"aud": "notification",
+			"exp": now.Add(time.Minute).Unix(),
+			"ext": map[string]interface{}{"user_uuid": "user-1"},
+		}
+		if mod != nil {
+			mod(c)
+		}
+		return c
+	}
+
+	cases := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"hmac", sign(t, jwt.SigningMethodHS256, "hs", []byte("shared-secret"), claims(nil)), true},
+		{"jwks rsa", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(nil)), true},
+		{"wrong issuer", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(func(c jwt.MapClaims) { c["iss"] = "evil" })), false},
+		{"wrong audience", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(func(c jwt.MapClaims) { c["aud"] = "other" })), false},
+		{"expired", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Minute).Unix() })), false},
+		{"no exp", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(func(c jwt.MapClaims) { delete(c, "exp") })), false},
+		{"no user", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(func(c jwt.MapClaims) { delete(c, "ext") })), false},
+		{"unknown kid", sign(t, jwt.SigningMethodES256, "ec-1", ecKey, claims(nil)), false},
+		// An RSA-keyed kid must not accept an HMAC token signed with the
+		// public modulus.
+		{"alg confusion", sign(t, jwt.SigningMethodHS256, "rsa-1", rsaKey.N.Bytes(), claims(nil)), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			user, err := v.Verify(tc.token)
+			if tc.ok && (err != nil || user != "user-1") {
+				t.Fatalf("Verify = %q, %v", user, err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected rejection, got user %q", user)
+			}
+		})
+	}
+
+	t.Run("jwks rotation", func(t *testing.T) {
+		writeJWKS(t, jwksFile, rsaJWK("rsa-1", rsaKey), ecJWK("ec-1", ecKey))
+		// Make sure the modification time differs on coarse filesystems.
+		later := now.Add(time.Hour)
+		if err := os.Chtimes(jwksFile, later, later); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(jwksCheckInterval)
+		token := sign(t, jwt.SigningMethodES256, "ec-1", ecKey, claims(nil))
+		if user, err := v.Verify(token); err != nil || user != "user-1" {
+			t.Fatalf("Verify after rotation = %q, %v", user, err)
+		}
+	})
+
+	if _, err := NewVerifier(Config{}); err == nil {
+		t.Fatalf("expected error without keys")
+	}
+}
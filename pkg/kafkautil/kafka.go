@@ -0,0 +1,66 @@
+package kafkautil
+
+import (
+	"context"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"notification-service/pkg/config"
+)
+
+// Reader is the subset of *kafka.Reader used by consumers, so tests can
+// substitute an in-memory broker.
+type Reader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Writer is the subset of *kafka.Writer used by producers.
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// NewGroupReader creates a consumer-group reader subscribed to topics.
+// Offsets are committed explicitly via CommitMessages after processing.
+func NewGroupReader(cfg config.KafkaConfig, topics []string) *kafka.Reader {
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     cfg.BootstrapServers,
+		GroupID:     cfg.GroupID,
+		GroupTopics: topics,
+		Dialer: &kafka.Dialer{
+			ClientID:  cfg.ClientID,
+			Timeout:   10 * time.Second,
+			DualStack: true,
+		},
+		MinBytes:    1,
+		MaxBytes:    10e6,
+		StartOffset: kafka.FirstOffset,
+	})
+}
+
+// NewWriter creates a writer that routes each message by its Topic field
+// and partitions by key, so events for one key stay ordered.
+func NewWriter(cfg config.KafkaConfig) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(cfg.BootstrapServers...),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: 10 * time.Millisecond,
+		Transport: &kafka.Transport{
+			ClientID: cfg.ClientID,
+		},
+	}
+}
+
+// Header returns the value of the named header, or "" if absent.
+func Header(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
@@ -12,6 +12,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -148,8 +150,14 @@ func (l *Logger) isLevelEnabled(level LogLevel) bool {
 	return level >= l.GetLevel()
 }
 
+// traceIDs 日志关联的 trace，来自 WithContext 时 context 中的 span。
+type traceIDs struct {
+	traceID string
+	spanID  string
+}
+
 // log 核心日志方法
-func (l *Logger) log(level LogLevel, message string, fields map[string]interface{}) {
+func (l *Logger) log(level LogLevel, message string, fields map[string]interface{}, ids traceIDs) {
 	if !l.isLevelEnabled(level) {
 		return
 	}
@@ -180,6 +188,8 @@ func (l *Logger) log(level LogLevel, message string, fields map[string]interface
 		Line:      line,
 		Function:  function,
 		Fields:    fields,
+		TraceID:   ids.traceID,
+		SpanID:    ids.spanID,
 	}
 
 	// 格式化输出
@@ -194,6 +204,9 @@ func (l *Logger) log(level LogLevel, message string, fields map[string]interface
 		// 文本格式
 		output = fmt.Sprintf("[%s] %s %s:%d %s - %s",
 			entry.Timestamp, entry.Level, entry.File, entry.Line, entry.Function, entry.Message)
+		if entry.TraceID != "" {
+			output += fmt.Sprintf(" trace_id=%s span_id=%s", entry.TraceID, entry.SpanID)
+		}
 		if len(fields) > 0 {
 			if fieldsJSON, err := json.Marshal(fields); err == nil {
 				output += fmt.Sprintf(" fields=%s", string(fieldsJSON))
@@ -228,7 +241,7 @@ func (l *Logger) Debug(message string, fields ...map[string]interface{}) {
 	if len(fields) > 0 {
 		f = fields[0]
 	}
-	l.log(DEBUG, message, f)
+	l.log(DEBUG, message, f, traceIDs{})
 }
 
 // Info 信息日志
@@ -237,13 +250,13 @@ func (l *Logger) Info(message string, fields ...map[string]interface{}) {
 	if len(fields) > 0 {
 		f = fields[0]
 	}
-	l.log(INFO, message, f)
+	l.log(INFO, message, f, traceIDs{})
 }
 
 // Infof 格式化信息日志
 func (l *Logger) Infof(format string, args ...interface{}) {
 	message := fmt.Sprintf(format, args...)
-	l.log(INFO, message, nil)
+	l.log(INFO, message, nil, traceIDs{})
 }
 
 // Warn 警告日志
@@ -252,13 +265,13 @@ func (l *Logger) Warn(message string, fields ...map[string]interface{}) {
 	if len(fields) > 0 {
 		f = fields[0]
 	}
-	l.log(WARN, message, f)
+	l.log(WARN, message, f, traceIDs{})
 }
 
 // Warnf 格式化警告日志
 func (l *Logger) Warnf(format string, args ...interface{}) {
 	message := fmt.Sprintf(format, args...)
-	l.log(WARN, message, nil)
+	l.log(WARN, message, nil, traceIDs{})
 }
 
 // Error 错误日志
@@ -267,13 +280,13 @@ func (l *Logger) Error(message string, fields ...map[string]interface{}) {
 	if len(fields) > 0 {
 		f = fields[0]
 	}
-	l.log(ERROR, message, f)
+	l.log(ERROR, message, f, traceIDs{})
 }
 
 // Errorf 格式化错误日志
 func (l *Logger) Errorf(format string, args ...interface{}) {
 	message := fmt.Sprintf(format, args...)
-	l.log(ERROR, message, nil)
+	l.log(ERROR, message, nil, traceIDs{})
 }
 
 // Fatal 致命错误日志
@@ -282,7 +295,7 @@ func (l *Logger) Fatal(message string, fields ...map[string]interface{}) {
 	if len(fields) > 0 {
 		f = fields[0]
 	}
-	l.log(FATAL, message, f)
+	l.log(FATAL, message, f, traceIDs{})
 	os.Exit(1)
 }
 
@@ -311,57 +324,70 @@ func (l *Logger) Close() error {
 type FieldLogger struct {
 	logger *Logger
 	fields map[string]interface{}
+	trace  traceIDs
+}
+
+// WithFields 追加字段，返回新的日志器。
+func (fl *FieldLogger) WithFields(fields map[string]interface{}) *FieldLogger {
+	merged := make(map[string]interface{}, len(fl.fields)+len(fields))
+	for k, v := range fl.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &FieldLogger{logger: fl.logger, fields: merged, trace: fl.trace}
 }
 
 // Infof 格式化信息日志
 func (fl *FieldLogger) Infof(format string, args ...interface{}) {
-	fl.logger.log(INFO, fmt.Sprintf(format, args...), fl.fields)
+	fl.logger.log(INFO, fmt.Sprintf(format, args...), fl.fields, fl.trace)
 }
 
 // Debug 调试日志
 func (fl *FieldLogger) Debug(message string) {
-	fl.logger.log(DEBUG, message, fl.fields)
+	fl.logger.log(DEBUG, message, fl.fields, fl.trace)
 }
 
 // Debugf 格式化调试日志
 func (fl *FieldLogger) Debugf(format string, args ...interface{}) {
-	fl.logger.log(DEBUG, fmt.Sprintf(format, args...), fl.fields)
+	fl.logger.log(DEBUG, fmt.Sprintf(format, args...), fl.fields, fl.trace)
 }
 
 // Info 信息日志
 func (fl *FieldLogger) Info(message string) {
-	fl.logger.log(INFO, message, fl.fields)
+	fl.logger.log(INFO, message, fl.fields, fl.trace)
 }
 
 // Warn 警告日志
 func (fl *FieldLogger) Warn(message string) {
-	fl.logger.log(WARN, message, fl.fields)
+	fl.logger.log(WARN, message, fl.fields, fl.trace)
 }
 
 // Warnf 格式化警告日志
 func (fl *FieldLogger) Warnf(format string, args ...interface{}) {
-	fl.logger.log(WARN, fmt.Sprintf(format, args...), fl.fields)
+	fl.logger.log(WARN, fmt.Sprintf(format, args...), fl.fields, fl.trace)
 }
 
 // Error 错误日志
 func (fl *FieldLogger) Error(message string) {
-	fl.logger.log(ERROR, message, fl.fields)
+	fl.logger.log(ERROR, message, fl.fields, fl.trace)
 }
 
 // Errorf 格式化错误日志
 func (fl *FieldLogger) Errorf(format string, args ...interface{}) {
-	fl.logger.log(ERROR, fmt.Sprintf(format, args...), fl.fields)
+	fl.logger.log(ERROR, fmt.Sprintf(format, args...), fl.fields, fl.trace)
 }
 
 // Fatal 致命错误日志
 func (fl *FieldLogger) Fatal(message string) {
-	fl.logger.log(FATAL, message, fl.fields)
+	fl.logger.log(FATAL, message, fl.fields, fl.trace)
 	os.Exit(1)
 }
 
 // Fatalf 格式化致命错误日志
 func (fl *FieldLogger) Fatalf(format string, args ...interface{}) {
-	fl.logger.log(FATAL, fmt.Sprintf(format, args...), fl.fields)
+	fl.logger.log(FATAL, fmt.Sprintf(format, args...), fl.fields, fl.trace)
 	os.Exit(1)
 }
 
@@ -460,10 +486,15 @@ func IsGlobalLoggerInitialized() bool {
 	return globalLogger != nil
 }
 
-// WithContext 根据 context 注入 request_id/user_uuid 字段，便于链路追踪。
+// WithContext 根据 context 注入 request_id/user_uuid 字段以及当前 span 的
+// trace_id/span_id，便于链路追踪。
 func WithContext(ctx context.Context) *FieldLogger {
 	fields := map[string]interface{}{}
+	var ids traceIDs
 	if ctx != nil {
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			ids = traceIDs{traceID: sc.TraceID().String(), spanID: sc.SpanID().String()}
+		}
 		if v := ctx.Value("request_id"); v != nil {
 			fields["request_id"] = v
 		}
@@ -471,5 +502,7 @@ func WithContext(ctx context.Context) *FieldLogger {
 			fields["user_uuid"] = v
 		}
 	}
-	return getGlobalLogger().WithFields(fields)
+	fl := getGlobalLogger().WithFields(fields)
+	fl.trace = ids
+	return fl
 }
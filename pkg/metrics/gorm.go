@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const gormStartKey = "metrics:start"
+
+type gormPlugin struct{}
+
+// GormPlugin records the latency and errors of every statement run through
+// the GORM DB it is installed on: db.Use(metrics.GormPlugin()).
+func GormPlugin() gorm.Plugin {
+	return gormPlugin{}
+}
+
+func (gormPlugin) Name() string {
+	return "notification:metrics"
+}
+
+func (gormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("metrics:before_create", gormStart),
+		cb.Create().After("gorm:create").Register("metrics:after_create", gormEnd("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", gormStart),
+		cb.Query().After("gorm:query").Register("metrics:after_query", gormEnd("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", gormStart),
+		cb.Update().After("gorm:update").Register("metrics:after_update", gormEnd("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", gormStart),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", gormEnd("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", gormStart),
+		cb.Row().After("gorm:row").Register("metrics:after_row", gormEnd("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", gormStart),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", gormEnd("raw")),
+	)
+}
+
+func gormStart(db *gorm.DB) {
+	db.InstanceSet(gormStartKey, time.Now())
+}
+
+func gormEnd(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(gormStartKey)
+		if !ok {
+			return
+		}
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		dbDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			dbErrors.WithLabelValues(operation, table).Inc()
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Package metrics defines the service's Prometheus metrics and serves them
+// on /metrics. HTTP, gRPC and GORM instrumentation record through the
+// Observe* functions; SSE hub and bridge counters are read at scrape time.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"notification-service/pkg/sse"
+)
+
+const namespace = "notification"
+
+// Registry holds every metric of the service, plus Go runtime and process
+// collectors.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route and status code.",
+	}, []string{"method", "route", "code"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route, excluding SSE streams.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	grpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "gRPC requests by method and status code.",
+	}, []string{"method", "code"})
+	grpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "gRPC request latency by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	notificationsCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_created_total",
+		Help:      "Notifications created by type and producer.",
+	}, []string{"type", "producer"})
+	notificationsRateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_rate_limited_total",
+		Help:      "Notifications over a rate limit by rule and action.",
+	}, []string{"rule", "action"})
+
+	sseRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sse_connections_rejected_total",
+		Help:      "SSE connections refused by admission control by reason.",
+	}, []string{"reason"})
+	bridgeLag = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sse_bridge_lag_seconds",
+		Help:      "Delay from publishing an envelope on the bridge to receiving it, including clock skew between instances.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	})
+
+	dbDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database statement latency by operation and table.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+	dbErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_query_errors_total",
+		Help:      "Failed database statements by operation and table; missing records are not errors.",
+	}, []string{"operation", "table"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		grpcRequests, grpcDuration,
+		notificationsCreated, notificationsRateLimited,
+		sseRejected, bridgeLag,
+		dbDuration, dbErrors,
+		newSSECollector(),
+	)
+	sse.SetBridgeLagObserver(func(d time.Duration) {
+		bridgeLag.Observe(d.Seconds())
+	})
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTPRequest records a finished HTTP request. Streams are counted
+// but kept out of the latency histogram, since their duration is the
+// connection lifetime.
+func ObserveHTTPRequest(method, route string, code int, d time.Duration, stream bool) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(code)).Inc()
+	if !stream {
+		httpDuration.WithLabelValues(method, route).Observe(d.Seconds())
+	}
+}
+
+// ObserveGRPCRequest records a finished gRPC call.
+func ObserveGRPCRequest(method, code string, d time.Duration) {
+	grpcRequests.WithLabelValues(method, code).Inc()
+	grpcDuration.WithLabelValues(method).Observe(d.Seconds())
+}
+
+// ObserveNotificationCreated counts a stored notification; producer is
+// empty for internal callers.
+func ObserveNotificationCreated(typ, producer string) {
+	if producer == "" {
+		producer = "internal"
+	}
+	notificationsCreated.WithLabelValues(typ, producer).Inc()
+}
+
+// ObserveRateLimited counts a notification over a rate limit.
+func ObserveRateLimited(rule, action string) {
+	notificationsRateLimited.WithLabelValues(rule, action).Inc()
+}
+
+// ObserveSSERejected counts an SSE connection refused by admission control.
+func ObserveSSERejected(reason string) {
+	sseRejected.WithLabelValues(reason).Inc()
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+
+	"notification-service/pkg/sse"
+)
+
+func TestSSECollectorReadsHub(t *testing.T) {
+	sub := sse.DefaultHub().Subscribe("metrics-user", sse.SubscribeOptions{})
+	defer sub.Close()
+	sse.DefaultHub().Publish("metrics-user", sse.Event{Type: "notification.created"})
+
+	c := newSSECollector()
+	if problems, err := testutil.CollectAndLint(c); err != nil || len(problems) > 0 {
+		t.Fatalf("lint: %v %+v", err, problems)
+	}
+	want := `
+# HELP notification_sse_connections Open SSE connections on this instance.
+# TYPE notification_sse_connections gauge
+notification_sse_connections 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "notification_sse_connections"); err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.CollectAndCount(c, "notification_sse_hub_events_total"); n != 7 {
+		t.Fatalf("expected 7 hub outcomes, got %d", n)
+	}
+}
+
+// latencySamples returns how many requests of route the latency histogram
+// has observed.
+func latencySamples(t *testing.T, route string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := httpDuration.WithLabelValues("GET", route).(prometheus.Metric).Write(&m); err != nil {
+		t.Fatalf("read histogram: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestObserveHTTPRequestSkipsStreamLatency(t *testing.T) {
+	// The collectors are process-wide, so compare against what earlier runs
+	// left behind.
+	requests := httpRequests.WithLabelValues("GET", "/test/stream", "200")
+	before := testutil.ToFloat64(requests)
+	streamBefore, listBefore := latencySamples(t, "/test/stream"), latencySamples(t, "/test/list")
+
+	ObserveHTTPRequest("GET", "/test/stream", 200, time.Hour, true)
+	ObserveHTTPRequest("GET", "/test/list", 200, time.Millisecond, false)
+
+	if v := testutil.ToFloat64(requests) - before; v != 1 {
+		t.Fatalf("stream request count increased by %v, want 1", v)
+	}
+	if n := latencySamples(t, "/test/stream") - streamBefore; n != 0 {
+		t.Fatalf("stream request observed %d latency samples, want 0", n)
+	}
+	if n := latencySamples(t, "/test/list") - listBefore; n != 1 {
+		t.Fatalf("list request observed %d latency samples, want 1", n)
+	}
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"notification-service/pkg/sse"
+)
+
+// sseCollector exports the default hub's counters and the bridge status,
+// which the sse package already keeps, at scrape time.
+type sseCollector struct {
+	connections   *prometheus.Desc
+	users         *prometheus.Desc
+	hubEvents     *prometheus.Desc
+	slowDisc      *prometheus.Desc
+	bridgeUp      *prometheus.Desc
+	bridgeBuffer  *prometheus.Desc
+	bridgeErrors  *prometheus.Desc
+	bridgeReconn  *prometheus.Desc
+	bridgeDropped *prometheus.Desc
+	bridgeSent    *prometheus.Desc
+	bridgeReject  *prometheus.Desc
+}
+
+func newSSECollector() *sseCollector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "sse", name), help, labels, nil)
+	}
+	return &sseCollector{
+		connections:   desc("connections", "Open SSE connections on this instance."),
+		users:         desc("users", "Users with at least one SSE connection on this instance."),
+		hubEvents:     desc("hub_events_total", "Hub publish and delivery counters by outcome; delivered/published is the average fan-out.", "outcome"),
+		slowDisc:      desc("slow_disconnects_total", "Subscribers disconnected for not draining their queue."),
+		bridgeUp:      desc("bridge_connected", "Whether the cross-instance bridge subscriber is connected."),
+		bridgeBuffer:  desc("bridge_buffered", "Envelopes buffered while the broker is unreachable."),
+		bridgeErrors:  desc("bridge_publish_errors_total", "Failed broker publishes, including buffer flushes."),
+		bridgeReconn:  desc("bridge_reconnects_total", "Bridge subscriber reconnects."),
+		bridgeDropped: desc("bridge_dropped_total", "Envelopes dropped because the bridge buffer was full."),
+		bridgeSent:    desc("bridge_published_total", "Bridge publishes by route.", "route"),
+		bridgeReject:  desc("bridge_rejected_total", "Incoming bridge messages rejected by reason.", "reason"),
+	}
+}
+
+func (c *sseCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		c.connections, c.users, c.hubEvents, c.slowDisc,
+		c.bridgeUp, c.bridgeBuffer, c.bridgeErrors, c.bridgeReconn, c.bridgeDropped, c.bridgeSent, c.bridgeReject,
+	} {
+		ch <- d
+	}
+}
+
+func (c *sseCollector) Collect(ch chan<- prometheus.Metric) {
+	hub := sse.DefaultHub()
+	ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(hub.ConnectionCount()))
+	ch <- prometheus.MustNewConstMetric(c.users, prometheus.GaugeValue, float64(len(hub.SubscriberCounts())))
+
+	st := hub.Stats()
+	for outcome, v := range map[string]int64{
+		"published":      st.Published,
+		"no_subscribers": st.NoSubscribers,
+		"delivered":      st.Delivered,
+		"coalesced":      st.Coalesced,
+		"dropped":        st.Dropped,
+		"overflow":       st.Overflows,
+		"resync":         st.Resyncs,
+	} {
+		ch <- prometheus.MustNewConstMetric(c.hubEvents, prometheus.CounterValue, float64(v), outcome)
+	}
+	ch <- prometheus.MustNewConstMetric(c.slowDisc, prometheus.CounterValue, float64(st.SlowDisconnects))
+
+	bs, _ := sse.CurrentBridgeStatus()
+	up := 0.0
+	if bs.State == sse.BridgeConnected.String() {
+		up = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.bridgeUp, prometheus.GaugeValue, up)
+	ch <- prometheus.MustNewConstMetric(c.bridgeBuffer, prometheus.GaugeValue, float64(bs.Buffered))
+	ch <- prometheus.MustNewConstMetric(c.bridgeErrors, prometheus.CounterValue, float64(bs.PublishErrors))
+	ch <- prometheus.MustNewConstMetric(c.bridgeReconn, prometheus.CounterValue, float64(bs.Reconnects))
+	ch <- prometheus.MustNewConstMetric(c.bridgeDropped, prometheus.CounterValue, float64(bs.Dropped))
+	for route, v := range map[string]int64{"routed": bs.Routed, "broadcast": bs.Broadcast, "skipped": bs.Skipped} {
+		ch <- prometheus.MustNewConstMetric(c.bridgeSent, prometheus.CounterValue, float64(v), route)
+	}
+	for reason, v := range bs.Rejected {
+		ch <- prometheus.MustNewConstMetric(c.bridgeReject, prometheus.CounterValue, float64(v), reason)
+	}
+}
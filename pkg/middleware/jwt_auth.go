@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"notification-service/pkg/errno"
+	"notification-service/pkg/jwtauth"
+	"notification-service/pkg/logger"
+	"notification-service/pkg/restapi"
+)
+
+// AuthUserUUIDKey holds the user authenticated by JWTAuthMiddleware; when
+// set it takes precedence over the X-User-UUID header.
+const AuthUserUUIDKey = "auth_user_uuid"
+
+// JWTAuthMiddleware 校验 Authorization: Bearer <JWT>，并将 claim 映射出的用户写入上下文。
+func JWTAuthMiddleware(v *jwtauth.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			restapi.FailedWithStatus(c, errno.ErrUnauthorized, http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+		userUUID, err := v.Verify(token)
+		if err != nil {
+			logger.WithContext(c.Request.Context()).Warnf("open api: jwt rejected path=%s error=%v", c.FullPath(), err)
+			restapi.FailedWithStatus(c, errno.ErrUnauthorized, http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+		c.Set(AuthUserUUIDKey, userUUID)
+		c.Set("user_uuid", userUUID)
+		c.Next()
+	}
+}
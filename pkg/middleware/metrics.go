@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"notification-service/pkg/metrics"
+)
+
+// MetricsMiddleware records request counts and latency per route. Requests
+// that match no route share the "unmatched" label to bound cardinality.
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		stream := strings.HasPrefix(c.Writer.Header().Get("Content-Type"), "text/event-stream")
+		metrics.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start), stream)
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"notification-service/pkg/restapi"
 )
 
-// RequestLogMiddleware logs HTTP requests with request_id and basic metrics.
+// RequestLogMiddleware logs HTTP requests with request_id, trace_id and basic metrics.
 func RequestLogMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
@@ -26,6 +26,6 @@ func RequestLogMiddleware() gin.HandlerFunc {
 			"status":     c.Writer.Status(),
 			"latency_ms": latency,
 		}
-		logger.WithFields(fields).Info("http request")
+		logger.WithContext(c.Request.Context()).WithFields(fields).Info("http request")
 	}
 }
@@ -0,0 +1,246 @@
+// Package producerauth identifies the services allowed to create
+// notifications and the notification types each of them may send.
+//
+// A producer authenticates with one of:
+//   - an API key (HTTP X-Api-Key header, gRPC x-api-key metadata);
+//   - an HMAC-SHA256 request signature over method, path, timestamp, a
+//     single-use nonce and body (HTTP only, see Registry.VerifySignature);
+//   - an mTLS client certificate whose URI SAN or common name is one of the
+//     producer's identities (gRPC only).
+package producerauth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Authentication errors.
+var (
+	ErrNoCredentials   = errors.New("producerauth: no credentials")
+	ErrUnknownProducer = errors.New("producerauth: unknown producer or key")
+	ErrBadSignature    = errors.New("producerauth: bad signature")
+	ErrStale           = errors.New("producerauth: stale timestamp")
+	ErrReplayed        = errors.New("producerauth: nonce already used")
+	// ErrNonceUnavailable means the replay check could not run, e.g. Redis
+	// is unreachable; signed requests are refused rather than let through.
+	ErrNonceUnavailable = errors.New("producerauth: nonce store unavailable")
+)
+
+const (
+	defaultMaxSkew = 5 * time.Minute
+	// maxNonceLen bounds the nonce stored per signed request.
+	maxNonceLen    = 128
+	nonceKeyPrefix = "go-video:notification:producer-nonce:"
+)
+
+// Producer is a service allowed to create notifications.
+type Producer struct {
+	Name       string
+	APIKeys    []string
+	Secrets    []string
+	Identities []string
+	// Types lists the notification types the producer may send; "*"
+	// allows any type.
+	Types []string
+}
+
+// Allows reports whether p may send notifications of type typ.
+func (p *Producer) Allows(typ string) bool {
+	for _, t := range p.Types {
+		if t == "*" || t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry holds the allowed producers.
+type Registry struct {
+	byName     map[string]*Producer
+	byAPIKey   map[string]*Producer
+	byIdentity map[string]*Producer
+	maxSkew    time.Duration
+	now        func() time.Time
+	// nonces records the nonces of accepted signed requests.
+	nonces atomic.Pointer[redis.Client]
+}
+
+// NewRegistry validates producers and indexes their credentials. maxSkew
+// bounds the age of signed requests; zero uses the default.
+func NewRegistry(producers []Producer, maxSkew time.Duration) (*Registry, error) {
+	if maxSkew <= 0 {
+		maxSkew = defaultMaxSkew
+	}
+	r := &Registry{
+		byName:     make(map[string]*Producer, len(producers)),
+		byAPIKey:   make(map[string]*Producer),
+		byIdentity: make(map[string]*Producer),
+		maxSkew:    maxSkew,
+		now:        time.Now,
+	}
+	for i := range producers {
+		p := &producers[i]
+		if p.Name == "" {
+			return nil, fmt.Errorf("producerauth: producer %d: missing name", i)
+		}
+		if _, dup := r.byName[p.Name]; dup {
+			return nil, fmt.Errorf("producerauth: producer %q: duplicate name", p.Name)
+		}
+		if len(p.APIKeys)+len(p.Secrets)+len(p.Identities) == 0 {
+			return nil, fmt.Errorf("producerauth: producer %q: no credentials", p.Name)
+		}
+		if len(p.Types) == 0 {
+			return nil, fmt.Errorf("producerauth: producer %q: no notification types", p.Name)
+		}
+		r.byName[p.Name] = p
+		for _, k := range p.APIKeys {
+			if other, dup := r.byAPIKey[k]; dup {
+				return nil, fmt.Errorf("producerauth: producers %q and %q share an api key", other.Name, p.Name)
+			}
+			r.byAPIKey[k] = p
+		}
+		for _, id := range p.Identities {
+			if other, dup := r.byIdentity[id]; dup {
+				return nil, fmt.Errorf("producerauth: producers %q and %q share identity %q", other.Name, p.Name, id)
+			}
+			r.byIdentity[id] = p
+		}
+	}
+	return r, nil
+}
+
+// Attach sets the Redis client used to reject replayed signed requests once
+// it is reachable. Until then signed requests fail with ErrNonceUnavailable.
+func (r *Registry) Attach(client *redis.Client) {
+	r.nonces.Store(client)
+}
+
+// AuthenticateAPIKey returns the producer owning key.
+func (r *Registry) AuthenticateAPIKey(key string) (*Producer, error) {
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+	for k, p := range r.byAPIKey {
+		// Compare every key in constant time so timing does not reveal a
+		// matching prefix.
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			return p, nil
+		}
+	}
+	return nil, ErrUnknownProducer
+}
+
+// AuthenticateIdentity returns the producer with one of the given mTLS
+// identities.
+func (r *Registry) AuthenticateIdentity(identities ...string) (*Producer, error) {
+	if len(identities) == 0 {
+		return nil, ErrNoCredentials
+	}
+	for _, id := range identities {
+		if p, ok := r.byIdentity[id]; ok {
+			return p, nil
+		}
+	}
+	return nil, ErrUnknownProducer
+}
+
+// Signature computes the hex HMAC-SHA256 a producer sends for a request:
+// the MAC of "METHOD\nPATH\nTIMESTAMP\nNONCE\n" followed by the raw body,
+// where TIMESTAMP is Unix seconds and NONCE is a random value unique to the
+// request.
+func Signature(secret, method, path, timestamp, nonce string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(method + "\n" + path + "\n" + timestamp + "\n" + nonce + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifySignature authenticates a signed request from the named producer.
+// Each nonce is accepted once: a captured request replayed while its
+// timestamp is still within the allowed skew fails with ErrReplayed.
+func (r *Registry) VerifySignature(ctx context.Context, name, method, path, timestamp, nonce, signature string, body []byte) (*Producer, error) {
+	if name == "" || signature == "" || nonce == "" {
+		return nil, ErrNoCredentials
+	}
+	if len(nonce) > maxNonceLen {
+		return nil, ErrBadSignature
+	}
+	p, ok := r.byName[name]
+	if !ok {
+		return nil, ErrUnknownProducer
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrStale
+	}
+	if skew := r.now().Sub(time.Unix(ts, 0)); skew > r.maxSkew || skew < -r.maxSkew {
+		return nil, ErrStale
+	}
+	for _, secret := range p.Secrets {
+		if hmac.Equal([]byte(signature), []byte(Signature(secret, method, path, timestamp, nonce, body))) {
+			// Record the nonce only for genuine requests so others cannot
+			// use up a producer's nonces.
+			if err := r.useNonce(ctx, p.Name, nonce); err != nil {
+				return nil, err
+			}
+			return p, nil
+		}
+	}
+	return nil, ErrBadSignature
+}
+
+// useNonce records nonce for the producer with SET NX. A timestamp is
+// accepted from maxSkew before to maxSkew after it, so the nonce is kept for
+// twice maxSkew to cover every moment the request could be replayed.
+func (r *Registry) useNonce(ctx context.Context, producer, nonce string) error {
+	client := r.nonces.Load()
+	if client == nil {
+		return ErrNonceUnavailable
+	}
+	ok, err := client.SetNX(ctx, nonceKeyPrefix+producer+":"+nonce, 1, 2*r.maxSkew).Result()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNonceUnavailable, err)
+	}
+	if !ok {
+		return ErrReplayed
+	}
+	return nil
+}
+
+type ctxKey struct{}
+
+// NewContext returns ctx carrying the authenticated producer.
+func NewContext(ctx context.Context, p *Producer) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext returns the producer authenticated for the request, if any.
+// Internal callers such as the Kafka consumer have none.
+func FromContext(ctx context.Context) (*Producer, bool) {
+	p, ok := ctx.Value(ctxKey{}).(*Producer)
+	return p, ok
+}
+
+var defaultRegistry atomic.Pointer[Registry]
+
+// SetDefaultRegistry installs the process-wide registry; nil disables
+// producer authentication.
+func SetDefaultRegistry(r *Registry) {
+	defaultRegistry.Store(r)
+}
+
+// DefaultRegistry returns the process-wide registry, or nil when producer
+// authentication is disabled.
+func DefaultRegistry() *Registry {
+	return defaultRegistry.Load()
+}
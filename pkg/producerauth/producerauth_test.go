@@ -0,0 +1,90 @@
+package producerauth
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRegistry(t *testing.T) {
+	reg, err := NewRegistry([]Producer{
+		{Name: "interaction", APIKeys: []string{"key-1"}, Types: []string{"like", "comment"}},
+		{Name: "billing", Secrets: []string{"old", "new"}, Identities: []string{"spiffe://go-video/billing"}, Types: []string{"*"}},
+	}, time.Minute)
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	reg.now = func() time.Time { return now }
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	reg.Attach(client)
+
+	p, err := reg.AuthenticateAPIKey("key-1")
+	if err != nil || p.Name != "interaction" {
+		t.Fatalf("AuthenticateAPIKey = %v, %v", p, err)
+	}
+	if !p.Allows("like") || p.Allows("payment") {
+		t.Fatalf("unexpected type allowance %v", p.Types)
+	}
+	if _, err := reg.AuthenticateAPIKey("key-2"); !errors.Is(err, ErrUnknownProducer) {
+		t.Fatalf("expected unknown key, got %v", err)
+	}
+	if p, err := reg.AuthenticateIdentity("CN-other", "spiffe://go-video/billing"); err != nil || p.Name != "billing" {
+		t.Fatalf("AuthenticateIdentity = %v, %v", p, err)
+	}
+
+	body := []byte(`{"user_uuid":"u","type":"payment"}`)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	const path = "/api/notification/v1/inner/notifications"
+	sig := Signature("old", "POST", path, ts, "n-1", body)
+	cases := []struct {
+		name, producer, ts, nonce, sig string
+		body                           []byte
+		err                            error
+	}{
+		{"valid with rotated secret", "billing", ts, "n-1", sig, body, nil},
+		{"replayed", "billing", ts, "n-1", sig, body, ErrReplayed},
+		{"nonce not signed", "billing", ts, "n-2", sig, body, ErrBadSignature},
+		{"no nonce", "billing", ts, "", sig, body, ErrNoCredentials},
+		{"tampered body", "billing", ts, "n-1", sig, []byte(`{"user_uuid":"v"}`), ErrBadSignature},
+		{"stale", "billing", strconv.FormatInt(now.Add(-2*time.Minute).Unix(), 10), "n-1", sig, body, ErrStale},
+		{"unknown producer", "nobody", ts, "n-1", sig, body, ErrUnknownProducer},
+		{"no signature", "billing", ts, "n-1", "", body, ErrNoCredentials},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := reg.VerifySignature(context.Background(), tc.producer, "POST", path, tc.ts, tc.nonce, tc.sig, tc.body)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("VerifySignature error = %v, want %v", err, tc.err)
+			}
+		})
+	}
+
+	// The nonce outlives every timestamp it could be replayed with.
+	if ttl := mr.TTL("go-video:notification:producer-nonce:billing:n-1"); ttl != 2*time.Minute {
+		t.Fatalf("nonce ttl = %s, want 2m", ttl)
+	}
+	// Without Redis the replay check cannot run and the request is refused.
+	mr.Close()
+	sig3 := Signature("new", "POST", path, ts, "n-3", body)
+	if _, err := reg.VerifySignature(context.Background(), "billing", "POST", path, ts, "n-3", sig3, body); !errors.Is(err, ErrNonceUnavailable) {
+		t.Fatalf("expected nonce store error, got %v", err)
+	}
+
+	if _, err := NewRegistry([]Producer{{Name: "a", APIKeys: []string{"k"}}}, 0); err == nil {
+		t.Fatalf("expected error for producer without types")
+	}
+	if _, err := NewRegistry([]Producer{
+		{Name: "a", APIKeys: []string{"k"}, Types: []string{"*"}},
+		{Name: "b", APIKeys: []string{"k"}, Types: []string{"*"}},
+	}, 0); err == nil {
+		t.Fatalf("expected error for shared api key")
+	}
+}
@@ -0,0 +1,295 @@
+// Package ratelimit caps how many notifications producers can create, in
+// total and per recipient, with Redis sliding windows shared by all
+// instances.
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Scope selects what a rule counts.
+type Scope string
+
+const (
+	// ScopeProducer counts per producer; calls without an authenticated
+	// producer are not counted.
+	ScopeProducer Scope = "producer"
+	// ScopeRecipient counts per recipient user.
+	ScopeRecipient Scope = "recipient"
+	// ScopeRecipientType counts per recipient and notification type.
+	ScopeRecipientType Scope = "recipient_type"
+)
+
+// Action is what happens to a notification over a limit. Actions are
+// ordered by severity; when several rules are exceeded the most severe wins.
+type Action int
+
+const (
+	// Allow creates and pushes the notification.
+	Allow Action = iota
+	// NoPush stores the notification without a real-time push.
+	NoPush
+	// Drop discards the notification while reporting success.
+	Drop
+	// Reject fails the call with a rate-limit error.
+	Reject
+)
+
+// ParseAction maps a config value to an Action.
+func ParseAction(s string) (Action, bool) {
+	switch s {
+	case "", "reject":
+		return Reject, true
+	case "drop":
+		return Drop, true
+	case "no_push":
+		return NoPush, true
+	default:
+		return Reject, false
+	}
+}
+
+func (a Action) String() string {
+	switch a {
+	case NoPush:
+		return "no_push"
+	case Drop:
+		return "drop"
+	case Reject:
+		return "reject"
+	default:
+		return "allow"
+	}
+}
+
+// Rule allows at most Limit notifications per Window within its scope.
+type Rule struct {
+	Name   string
+	Scope  Scope
+	Limit  int
+	Window time.Duration
+	Action Action
+	// Type and Producer restrict the rule to one notification type or
+	// producer; empty matches all.
+	Type     string
+	Producer string
+}
+
+// Subject describes the notification being created.
+type Subject struct {
+	Producer  string
+	Recipient string
+	Type      string
+}
+
+// Decision is the outcome of Limiter.Allow.
+type Decision struct {
+	Action Action
+	// Rule names the rule that decided the action.
+	Rule string
+	// RetryAfter is when the rejecting or dropping rule admits again.
+	RetryAfter time.Duration
+
+	// keys and member identify the counted call for Refund.
+	keys   []string
+	member string
+}
+
+// RejectedError is the cause of a rejected call; adapters read RetryAfter
+// to tell callers when to try again.
+type RejectedError struct {
+	Rule       string
+	RetryAfter time.Duration
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("ratelimit: rejected by rule %s, retry after %s", e.Rule, e.RetryAfter)
+}
+
+const keyPrefix = "go-video:notification:ratelimit:"
+
+// slidingWindow checks every rule key and, unless a blocking (reject or
+// drop) rule is exceeded, records the call in all of them, so notifications
+// that are not created do not use up quota.
+//
+// KEYS: one per rule. ARGV: now_ms, member, then limit, window_ms and
+// blocking (1/0) per key. Returns one exceeded flag per key followed by the
+// retry-after in ms of the blocking rules.
+var slidingWindow = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local member = ARGV[2]
+local n = #KEYS
+local out = {}
+local block = false
+local retry = 0
+for i = 1, n do
+  local limit = tonumber(ARGV[3*i])
+  local window = tonumber(ARGV[3*i+1])
+  redis.call('ZREMRANGEBYSCORE', KEYS[i], '-inf', now - window)
+  out[i] = 0
+  if redis.call('ZCARD', KEYS[i]) >= limit then
+    out[i] = 1
+    if ARGV[3*i+2] == '1' then
+      block = true
+      local oldest = redis.call('ZRANGE', KEYS[i], 0, 0, 'WITHSCORES')
+      local r = tonumber(oldest[2]) + window - now
+      if r > retry then retry = r end
+    end
+  end
+end
+if not block then
+  for i = 1, n do
+    redis.call('ZADD', KEYS[i], now, member)
+    redis.call('PEXPIRE', KEYS[i], tonumber(ARGV[3*i+1]))
+  end
+end
+out[n+1] = retry
+return out
+`)
+
+// Limiter evaluates rules against Redis. Until a client is attached every
+// call is allowed.
+type Limiter struct {
+	rules  []Rule
+	client atomic.Pointer[redis.Client]
+	now    func() time.Time
+}
+
+// New validates rules and constructs a Limiter.
+func New(rules []Rule) (*Limiter, error) {
+	seen := make(map[string]bool, len(rules))
+	for i, r := range rules {
+		switch {
+		case r.Name == "":
+			return nil, fmt.Errorf("ratelimit: rule %d: missing name", i)
+		case seen[r.Name]:
+			return nil, fmt.Errorf("ratelimit: rule %q: duplicate name", r.Name)
+		case r.Scope != ScopeProducer && r.Scope != ScopeRecipient && r.Scope != ScopeRecipientType:
+			return nil, fmt.Errorf("ratelimit: rule %q: unknown scope %q", r.Name, r.Scope)
+		case r.Limit <= 0 || r.Window < time.Millisecond:
+			return nil, fmt.Errorf("ratelimit: rule %q: limit and window must be positive", r.Name)
+		case r.Action == Allow:
+			return nil, fmt.Errorf("ratelimit: rule %q: missing action", r.Name)
+		}
+		seen[r.Name] = true
+	}
+	return &Limiter{rules: rules, now: time.Now}, nil
+}
+
+// Attach sets the Redis client once it is reachable.
+func (l *Limiter) Attach(client *redis.Client) {
+	l.client.Store(client)
+}
+
+// key returns the Redis key of rule r for s, or false if r does not apply.
+func (r *Rule) key(s Subject) (string, bool) {
+	if (r.Type != "" && r.Type != s.Type) || (r.Producer != "" && r.Producer != s.Producer) {
+		return "", false
+	}
+	switch r.Scope {
+	case ScopeProducer:
+		if s.Producer == "" {
+			return "", false
+		}
+		return keyPrefix + r.Name + ":" + s.Producer, true
+	case ScopeRecipient:
+		return keyPrefix + r.Name + ":" + s.Recipient, true
+	default:
+		return keyPrefix + r.Name + ":" + s.Recipient + ":" + s.Type, true
+	}
+}
+
+// Allow decides what to do with a notification for s and counts it unless
+// it is rejected or dropped; callers that then fail to create it call
+// Refund. Errors leave the decision at Allow so callers can fail open.
+func (l *Limiter) Allow(ctx context.Context, s Subject) (Decision, error) {
+	client := l.client.Load()
+	if client == nil {
+		return Decision{}, nil
+	}
+
+	var (
+		rules []*Rule
+		keys  []string
+	)
+	for i := range l.rules {
+		if k, ok := l.rules[i].key(s); ok {
+			rules = append(rules, &l.rules[i])
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return Decision{}, nil
+	}
+
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	now := l.now()
+	member := strconv.FormatInt(now.UnixNano(), 36) + hex.EncodeToString(id[:])
+	args := make([]interface{}, 0, 2+3*len(rules))
+	args = append(args, now.UnixMilli(), member)
+	for _, r := range rules {
+		blocking := 0
+		if r.Action >= Drop {
+			blocking = 1
+		}
+		args = append(args, r.Limit, r.Window.Milliseconds(), blocking)
+	}
+
+	res, err := slidingWindow.Run(ctx, client, keys, args...).Int64Slice()
+	if err != nil {
+		return Decision{}, fmt.Errorf("ratelimit: %w", err)
+	}
+	var d Decision
+	for i, r := range rules {
+		if res[i] == 1 && r.Action > d.Action {
+			d.Action, d.Rule = r.Action, r.Name
+		}
+	}
+	if d.Action >= Drop {
+		d.RetryAfter = time.Duration(res[len(rules)]) * time.Millisecond
+	} else {
+		d.keys, d.member = keys, member
+	}
+	return d, nil
+}
+
+// Refund returns the quota a counted call used, for callers that failed to
+// create the notification after Allow. Decisions that did not count the call
+// are ignored.
+func (l *Limiter) Refund(ctx context.Context, d Decision) error {
+	client := l.client.Load()
+	if client == nil || len(d.keys) == 0 {
+		return nil
+	}
+	_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, k := range d.keys {
+			pipe.ZRem(ctx, k, d.member)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("ratelimit: refund: %w", err)
+	}
+	return nil
+}
+
+var defaultLimiter atomic.Pointer[Limiter]
+
+// SetDefault installs the process-wide limiter; nil disables rate limiting.
+func SetDefault(l *Limiter) {
+	defaultLimiter.Store(l)
+}
+
+// Default returns the process-wide limiter, or nil when disabled.
+func Default() *Limiter {
+	return defaultLimiter.Load()
+}
@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestLimiter(t *testing.T, rules ...Rule) (*Limiter, *time.Time) {
+	t.Helper()
+	l, err := New(rules)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	l.Attach(client)
+	now := time.Unix(1_700_000_000, 0)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLimiterSlidingWindow(t *testing.T) {
+	l, now := newTestLimiter(t, Rule{Name: "likes", Scope: ScopeRecipientType, Type: "like", Limit: 2, Window: time.Hour, Action: Drop})
+	ctx := context.Background()
+	like := Subject{Producer: "interaction", Recipient: "u1", Type: "like"}
+
+	for i := 0; i < 2; i++ {
+		if d, err := l.Allow(ctx, like); err != nil || d.Action != Allow {
+			t.Fatalf("call %d: %+v, %v", i, d, err)
+		}
+		*now = now.Add(10 * time.Minute)
+	}
+	d, err := l.Allow(ctx, like)
+	if err != nil || d.Action != Drop || d.Rule != "likes" {
+		t.Fatalf("expected drop, got %+v, %v", d, err)
+	}
+	// The first like leaves the window an hour after it was counted.
+	if d.RetryAfter != 40*time.Minute {
+		t.Fatalf("RetryAfter = %s", d.RetryAfter)
+	}
+
+	// Other types and recipients have their own windows.
+	for _, s := range []Subject{{Recipient: "u1", Type: "comment"}, {Recipient: "u2", Type: "like"}} {
+		if d, _ := l.Allow(ctx, s); d.Action != Allow {
+			t.Fatalf("%+v limited: %+v", s, d)
+		}
+	}
+
+	*now = now.Add(40 * time.Minute)
+	if d, _ := l.Allow(ctx, like); d.Action != Allow {
+		t.Fatalf("expected window to slide, got %+v", d)
+	}
+}
+
+func TestLimiterMostSevereActionWins(t *testing.T) {
+	l, _ := newTestLimiter(t,
+		Rule{Name: "recipient", Scope: ScopeRecipient, Limit: 1, Window: time.Minute, Action: NoPush},
+		Rule{Name: "producer", Scope: ScopeProducer, Limit: 2, Window: time.Minute, Action: Reject},
+	)
+	ctx := context.Background()
+	s := Subject{Producer: "p", Recipient: "u", Type: "x"}
+
+	want := []Action{Allow, NoPush, Reject, Reject}
+	for i, w := range want {
+		d, err := l.Allow(ctx, s)
+		if err != nil || d.Action != w {
+			t.Fatalf("call %d: %+v, %v; want %s", i, d, err, w)
+		}
+	}
+	// Calls without a producer are not counted against producer rules.
+	if d, _ := l.Allow(ctx, Subject{Recipient: "other"}); d.Action != Allow {
+		t.Fatalf("anonymous call limited: %+v", d)
+	}
+}
+
+func TestLimiterRefund(t *testing.T) {
+	l, _ := newTestLimiter(t, Rule{Name: "recipient", Scope: ScopeRecipient, Limit: 1, Window: time.Minute, Action: Reject})
+	ctx := context.Background()
+	s := Subject{Recipient: "u"}
+
+	d, err := l.Allow(ctx, s)
+	if err != nil || d.Action != Allow {
+		t.Fatalf("first call: %+v, %v", d, err)
+	}
+	// A notification that was not created gives its quota back.
+	if err := l.Refund(ctx, d); err != nil {
+		t.Fatalf("Refund: %v", err)
+	}
+	if d, _ := l.Allow(ctx, s); d.Action != Allow {
+		t.Fatalf("refunded quota not available: %+v", d)
+	}
+	rejected, _ := l.Allow(ctx, s)
+	if rejected.Action != Reject || rejected.RetryAfter != time.Minute {
+		t.Fatalf("expected reject with retry-after, got %+v", rejected)
+	}
+	// Rejected calls were never counted.
+	if err := l.Refund(ctx, rejected); err != nil {
+		t.Fatalf("Refund rejected: %v", err)
+	}
+	if d, _ := l.Allow(ctx, s); d.Action != Reject {
+		t.Fatalf("refunding a rejected call freed quota: %+v", d)
+	}
+}
+
+func TestLimiterAllowsWithoutRedis(t *testing.T) {
+	l, err := New([]Rule{{Name: "r", Scope: ScopeRecipient, Limit: 1, Window: time.Minute, Action: Reject}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if d, err := l.Allow(context.Background(), Subject{Recipient: "u"}); err != nil || d.Action != Allow {
+			t.Fatalf("expected allow before Redis attaches, got %+v, %v", d, err)
+		}
+	}
+	if _, err := New([]Rule{{Name: "r", Scope: "tenant", Limit: 1, Window: time.Minute, Action: Reject}}); err == nil {
+		t.Fatalf("expected error for unknown scope")
+	}
+}
@@ -0,0 +1,190 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/naming/endpoints"
+
+	"notification-service/pkg/logger"
+)
+
+// EtcdOptions configures EtcdRegistry.
+type EtcdOptions struct {
+	// Prefix is prepended to the service name to form the resolver target,
+	// e.g. "go-video/services".
+	Prefix string
+	// TTL is how long an instance stays registered without a refresh.
+	TTL time.Duration
+	// RefreshInterval is how often the lease is kept alive; it must be
+	// shorter than TTL.
+	RefreshInterval time.Duration
+}
+
+// EtcdRegistry registers instances under a lease that is refreshed in the
+// background. Keys follow the layout of etcd's gRPC naming resolver, so
+// other services discover the instances by dialing
+// "etcd:///<prefix>/<service name>" with go.etcd.io/etcd/client/v3/naming/resolver.
+// If the lease expires, e.g. while etcd was unreachable, the instance is
+// registered again under a new lease.
+type EtcdRegistry struct {
+	cli  *clientv3.Client
+	opts EtcdOptions
+
+	mu      sync.Mutex
+	inst    Instance
+	manager endpoints.Manager
+	lease   clientv3.LeaseID
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var _ Registry = (*EtcdRegistry)(nil)
+
+// NewEtcdRegistry returns a registry backed by cli.
+func NewEtcdRegistry(cli *clientv3.Client, opts EtcdOptions) *EtcdRegistry {
+	return &EtcdRegistry{cli: cli, opts: opts}
+}
+
+// Target returns the resolver target of a service.
+func (r *EtcdRegistry) Target(service string) string {
+	return path.Join(r.opts.Prefix, service)
+}
+
+func (r *EtcdRegistry) key(inst Instance) string {
+	return r.Target(inst.Name) + "/" + inst.ID
+}
+
+// Register implements Registry.
+func (r *EtcdRegistry) Register(ctx context.Context, inst Instance) error {
+	manager, err := endpoints.NewManager(r.cli, r.Target(inst.Name))
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	if r.cancel != nil {
+		r.mu.Unlock()
+		return errors.New("registry: instance already registered")
+	}
+	r.inst, r.manager = inst, manager
+	loopCtx, cancel := context.WithCancel(context.Background())
+	r.cancel, r.done = cancel, make(chan struct{})
+	r.mu.Unlock()
+
+	err = r.register(ctx)
+	go r.keepAlive(loopCtx)
+	return err
+}
+
+// Lookup returns the registered instances of a service.
+func (r *EtcdRegistry) Lookup(ctx context.Context, service string) ([]Instance, error) {
+	manager, err := endpoints.NewManager(r.cli, r.Target(service))
+	if err != nil {
+		return nil, err
+	}
+	eps, err := manager.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prefix := r.Target(service) + "/"
+	instances := make([]Instance, 0, len(eps))
+	for key, ep := range eps {
+		instances = append(instances, Instance{Name: service, ID: key[len(prefix):], Addr: ep.Addr})
+	}
+	return instances, nil
+}
+
+// Deregister implements Registry. Revoking the lease removes the key even
+// if the delete is lost.
+func (r *EtcdRegistry) Deregister(ctx context.Context) error {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	<-done
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cancel = nil
+	err := r.manager.DeleteEndpoint(ctx, r.key(r.inst))
+	if r.lease != 0 {
+		if _, revokeErr := r.cli.Revoke(ctx, r.lease); revokeErr != nil && !errors.Is(revokeErr, rpctypes.ErrLeaseNotFound) {
+			err = errors.Join(err, revokeErr)
+		}
+		r.lease = 0
+	}
+	return err
+}
+
+// register publishes the instance under a new lease.
+func (r *EtcdRegistry) register(ctx context.Context) error {
+	ttl := int64(r.opts.TTL / time.Second)
+	if ttl < 1 {
+		ttl = 1
+	}
+	grant, err := r.cli.Grant(ctx, ttl)
+	if err != nil {
+		return fmt.Errorf("grant lease: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err = r.manager.AddEndpoint(ctx, r.key(r.inst), endpoints.Endpoint{Addr: r.inst.Addr}, clientv3.WithLease(grant.ID))
+	if err != nil {
+		return fmt.Errorf("put endpoint: %w", err)
+	}
+	r.lease = grant.ID
+	return nil
+}
+
+func (r *EtcdRegistry) keepAlive(ctx context.Context) {
+	defer close(r.done)
+	ticker := time.NewTicker(r.opts.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := r.refresh(ctx); err != nil && ctx.Err() == nil {
+			logger.Warnf("registry: refresh registration failed key=%s error=%v", r.key(r.inst), err)
+		}
+	}
+}
+
+// refresh keeps the lease alive, registering again when there is no live
+// lease.
+func (r *EtcdRegistry) refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.opts.RefreshInterval)
+	defer cancel()
+
+	r.mu.Lock()
+	lease := r.lease
+	r.mu.Unlock()
+	if lease != 0 {
+		_, err := r.cli.KeepAliveOnce(ctx, lease)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, rpctypes.ErrLeaseNotFound) {
+			return err
+		}
+		logger.Warnf("registry: lease expired, registering again key=%s", r.key(r.inst))
+	}
+	if err := r.register(ctx); err != nil {
+		return err
+	}
+	logger.Infof("registry: registered key=%s addr=%s", r.key(r.inst), r.inst.Addr)
+	return nil
+}
@@ -0,0 +1,103 @@
+package registry
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+)
+
+func startEtcd(t *testing.T) *clientv3.Client {
+	t.Helper()
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+	local := []url.URL{{Scheme: "http", Host: "127.0.0.1:0"}}
+	cfg.ListenClientUrls, cfg.AdvertiseClientUrls = local, local
+	peer := []url.URL{{Scheme: "http", Host: "127.0.0.1:0"}}
+	cfg.ListenPeerUrls = peer
+
+	e, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatalf("start etcd: %v", err)
+	}
+	t.Cleanup(e.Close)
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		t.Fatalf("etcd not ready")
+	}
+
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{e.Clients[0].Addr().String()},
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("etcd client: %v", err)
+	}
+	t.Cleanup(func() { _ = cli.Close() })
+	return cli
+}
+
+func waitForInstances(t *testing.T, r *EtcdRegistry, service string, want int) []Instance {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		got, err := r.Lookup(t.Context(), service)
+		if err == nil && len(got) == want {
+			return got
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("instances = %v (err %v), want %d", got, err, want)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestEtcdRegistryKeepsInstanceRegistered(t *testing.T) {
+	cli := startEtcd(t)
+	r := NewEtcdRegistry(cli, EtcdOptions{Prefix: "go-video/services", TTL: 2 * time.Second, RefreshInterval: 200 * time.Millisecond})
+
+	inst := Instance{Name: "notification-service", ID: "n-1", Addr: "10.0.0.1:9096"}
+	if err := r.Register(t.Context(), inst); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if got := waitForInstances(t, r, inst.Name, 1); got[0] != inst {
+		t.Fatalf("registered %+v, want %+v", got[0], inst)
+	}
+
+	// The lease outlives its TTL while it is refreshed.
+	time.Sleep(3 * time.Second)
+	waitForInstances(t, r, inst.Name, 1)
+
+	// A lost lease, e.g. after etcd was unreachable for longer than the TTL,
+	// is replaced.
+	r.mu.Lock()
+	lease := r.lease
+	r.mu.Unlock()
+	if _, err := cli.Revoke(context.Background(), lease); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		r.mu.Lock()
+		renewed := r.lease != lease && r.lease != 0
+		r.mu.Unlock()
+		if renewed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("instance not registered again after losing its lease")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	waitForInstances(t, r, inst.Name, 1)
+
+	if err := r.Deregister(t.Context()); err != nil {
+		t.Fatalf("deregister: %v", err)
+	}
+	waitForInstances(t, r, inst.Name, 0)
+}
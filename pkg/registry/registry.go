@@ -0,0 +1,59 @@
+// Package registry publishes this instance's gRPC endpoint in a service
+// registry so other go-video services can discover it.
+package registry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+)
+
+// Instance is one registered endpoint of a service.
+type Instance struct {
+	// Name is the service name clients resolve, e.g. "notification-service".
+	Name string
+	// ID distinguishes the instances of a service.
+	ID string
+	// Addr is the host:port clients dial.
+	Addr string
+}
+
+// Registry keeps an instance registered while it is serving.
+type Registry interface {
+	// Register publishes inst and keeps it alive in the background until
+	// Deregister. An error means the first attempt failed; registration is
+	// still retried in the background.
+	Register(ctx context.Context, inst Instance) error
+	// Deregister withdraws the instance and stops keeping it alive.
+	Deregister(ctx context.Context) error
+}
+
+// DefaultInstanceID identifies the instance by host name, which is the pod
+// name on Kubernetes.
+func DefaultInstanceID(service string) string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return service + "-" + host
+}
+
+// LocalIP returns the first non-loopback IPv4 address of this host, used
+// as the registered host when none is configured.
+func LocalIP() (string, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "", err
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() {
+			continue
+		}
+		if ip := ipNet.IP.To4(); ip != nil {
+			return ip.String(), nil
+		}
+	}
+	return "", errors.New("registry: no non-loopback IPv4 address")
+}
@@ -0,0 +1,195 @@
+package sse
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrEvicted is reported by Subscription.Err when a newer connection of the
+// same user took the slot.
+var ErrEvicted = errors.New("sse: evicted by a newer connection")
+
+// EvictedEventType is the last event of a stream evicted by a newer
+// connection of the same user. Clients must close their EventSource and not
+// reconnect on their own when they receive it; otherwise two tabs over the
+// per-user cap keep evicting each other. It is sent with EvictedRetry so
+// that browsers which reconnect anyway back off for a long time.
+const EvictedEventType = "evicted"
+
+// EvictedRetry is the reconnect hint sent with EvictedEventType.
+const EvictedRetry = time.Hour
+
+// UserLimitAction decides what happens when a user is at the per-user cap.
+type UserLimitAction int
+
+const (
+	// UserLimitEvictOldest disconnects the user's oldest connection.
+	UserLimitEvictOldest UserLimitAction = iota
+	// UserLimitReject rejects the new connection.
+	UserLimitReject
+)
+
+// ParseUserLimitAction maps a config value to a UserLimitAction.
+func ParseUserLimitAction(s string) (UserLimitAction, bool) {
+	switch s {
+	case "", "evict_oldest":
+		return UserLimitEvictOldest, true
+	case "reject":
+		return UserLimitReject, true
+	default:
+		return UserLimitEvictOldest, false
+	}
+}
+
+// Admission rejection reasons.
+const (
+	AdmitInstanceLimit = "instance_limit"
+	AdmitUserLimit     = "user_limit"
+	AdmitReconnectRate = "reconnect_rate"
+)
+
+const defaultAdmissionRetryAfter = 5 * time.Second
+
+// AdmissionPolicy caps stream connections. Zero values disable a limit.
+type AdmissionPolicy struct {
+	// MaxPerInstance caps the connections of this instance; new connections
+	// over it are always rejected.
+	MaxPerInstance int
+	// MaxPerUser caps the connections of one user on this instance.
+	MaxPerUser  int
+	OnUserLimit UserLimitAction
+	// ReconnectRate and ReconnectBurst bound how fast one user may open
+	// connections, to absorb reconnect storms.
+	ReconnectRate  rate.Limit
+	ReconnectBurst int
+	// RetryAfter is the back-off suggested to rejected clients; zero uses
+	// the default.
+	RetryAfter time.Duration
+}
+
+// AdmissionError explains a rejected connection.
+type AdmissionError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *AdmissionError) Error() string {
+	return fmt.Sprintf("sse: connection rejected reason=%s retry_after=%s", e.Reason, e.RetryAfter)
+}
+
+// limiterIdle is how long an unused per-user limiter is kept.
+const limiterIdle = 10 * time.Minute
+
+type userLimiter struct {
+	l        *rate.Limiter
+	lastSeen time.Time
+}
+
+// Admission applies an AdmissionPolicy in front of a hub. The check and the
+// subscribe happen under one lock, so concurrent connects cannot overshoot
+// the caps.
+type Admission struct {
+	policy AdmissionPolicy
+
+	mu        sync.Mutex
+	limiters  map[string]*userLimiter
+	lastSweep time.Time
+}
+
+// NewAdmission constructs an Admission.
+func NewAdmission(p AdmissionPolicy) *Admission {
+	if p.RetryAfter <= 0 {
+		p.RetryAfter = defaultAdmissionRetryAfter
+	}
+	if p.ReconnectRate > 0 && p.ReconnectBurst <= 0 {
+		p.ReconnectBurst = 1
+	}
+	return &Admission{policy: p, limiters: make(map[string]*userLimiter), lastSweep: time.Now()}
+}
+
+// Subscribe admits a new connection and subscribes it to h, evicting the
+// user's oldest connections if the policy says so. Rejections are
+// *AdmissionError.
+func (a *Admission) Subscribe(h Hub, userUUID string, opts SubscribeOptions) (*Subscription, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	if d, ok := a.reconnectDelay(userUUID, now); !ok {
+		return nil, &AdmissionError{Reason: AdmitReconnectRate, RetryAfter: max(d, time.Second)}
+	}
+
+	p := a.policy
+	if p.MaxPerUser > 0 {
+		if n := h.UserConnectionCount(userUUID); n >= p.MaxPerUser {
+			if p.OnUserLimit == UserLimitReject {
+				return nil, &AdmissionError{Reason: AdmitUserLimit, RetryAfter: p.RetryAfter}
+			}
+			// Evicting frees slots for this user without changing the
+			// instance total, so it runs before the instance check.
+			conns := h.UserConnections(userUUID)
+			for i := 0; i <= len(conns)-p.MaxPerUser; i++ {
+				h.Disconnect(userUUID, conns[i].ID, ErrEvicted)
+			}
+		}
+	}
+	if p.MaxPerInstance > 0 && h.ConnectionCount() >= p.MaxPerInstance {
+		return nil, &AdmissionError{Reason: AdmitInstanceLimit, RetryAfter: p.RetryAfter}
+	}
+	return h.Subscribe(userUUID, opts), nil
+}
+
+// reconnectDelay takes a token from the user's limiter; when none is
+// available it reports how long until one is.
+func (a *Admission) reconnectDelay(userUUID string, now time.Time) (time.Duration, bool) {
+	if a.policy.ReconnectRate <= 0 {
+		return 0, true
+	}
+	if now.Sub(a.lastSweep) > limiterIdle {
+		for u, ul := range a.limiters {
+			if now.Sub(ul.lastSeen) > limiterIdle {
+				delete(a.limiters, u)
+			}
+		}
+		a.lastSweep = now
+	}
+
+	ul, ok := a.limiters[userUUID]
+	if !ok {
+		ul = &userLimiter{l: rate.NewLimiter(a.policy.ReconnectRate, a.policy.ReconnectBurst)}
+		a.limiters[userUUID] = ul
+	}
+	ul.lastSeen = now
+	r := ul.l.ReserveN(now, 1)
+	if d := r.DelayFrom(now); d > 0 {
+		r.CancelAt(now)
+		return d, false
+	}
+	return 0, true
+}
+
+var defaultAdmission atomic.Pointer[Admission]
+
+func init() {
+	defaultAdmission.Store(NewAdmission(AdmissionPolicy{}))
+}
+
+// SetAdmissionPolicy replaces the policy applied by Admit.
+func SetAdmissionPolicy(p AdmissionPolicy) {
+	defaultAdmission.Store(NewAdmission(p))
+}
+
+// Admit admits a stream connection to the default hub under the configured
+// admission policy.
+func Admit(userUUID string, opts SubscribeOptions) (*Subscription, error) {
+	a := defaultAdmission.Load()
+	if Draining() {
+		return nil, &AdmissionError{Reason: AdmitDraining, RetryAfter: a.policy.RetryAfter}
+	}
+	return a.Subscribe(DefaultHub(), userUUID, opts)
+}
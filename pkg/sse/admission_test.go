@@ -0,0 +1,97 @@
+package sse
+
+import (
+	"errors"
+	"testing"
+)
+
+func admissionReason(err error) string {
+	var rejected *AdmissionError
+	if errors.As(err, &rejected) {
+		return rejected.Reason
+	}
+	return ""
+}
+
+func TestAdmissionEvictsOldestConnection(t *testing.T) {
+	h := NewShardedHub(1)
+	a := NewAdmission(AdmissionPolicy{MaxPerUser: 2})
+
+	var subs []*Subscription
+	for i := 0; i < 3; i++ {
+		sub, err := a.Subscribe(h, "u", SubscribeOptions{})
+		if err != nil {
+			t.Fatalf("Subscribe: %v", err)
+		}
+		defer sub.Close()
+		subs = append(subs, sub)
+	}
+
+	select {
+	case <-subs[0].Done():
+	default:
+		t.Fatalf("oldest connection not evicted")
+	}
+	if !errors.Is(subs[0].Err(), ErrEvicted) {
+		t.Fatalf("Err = %v", subs[0].Err())
+	}
+	if n := h.UserConnectionCount("u"); n != 2 {
+		t.Fatalf("UserConnectionCount = %d", n)
+	}
+}
+
+func TestAdmissionRejects(t *testing.T) {
+	t.Run("user limit", func(t *testing.T) {
+		h := NewShardedHub(1)
+		a := NewAdmission(AdmissionPolicy{MaxPerUser: 1, OnUserLimit: UserLimitReject})
+		sub, err := a.Subscribe(h, "u", SubscribeOptions{})
+		if err != nil {
+			t.Fatalf("Subscribe: %v", err)
+		}
+		defer sub.Close()
+		if _, err := a.Subscribe(h, "u", SubscribeOptions{}); admissionReason(err) != AdmitUserLimit {
+			t.Fatalf("expected user limit, got %v", err)
+		}
+		if sub, err := a.Subscribe(h, "other", SubscribeOptions{}); err != nil {
+			t.Fatalf("other user rejected: %v", err)
+		} else {
+			sub.Close()
+		}
+	})
+
+	t.Run("instance limit", func(t *testing.T) {
+		h := NewShardedHub(1)
+		a := NewAdmission(AdmissionPolicy{MaxPerInstance: 1, MaxPerUser: 1})
+		sub, err := a.Subscribe(h, "a", SubscribeOptions{})
+		if err != nil {
+			t.Fatalf("Subscribe: %v", err)
+		}
+		defer sub.Close()
+		if _, err := a.Subscribe(h, "b", SubscribeOptions{}); admissionReason(err) != AdmitInstanceLimit {
+			t.Fatalf("expected instance limit, got %v", err)
+		}
+		// Replacing one's own connection does not need a free slot.
+		again, err := a.Subscribe(h, "a", SubscribeOptions{})
+		if err != nil {
+			t.Fatalf("replacement rejected: %v", err)
+		}
+		again.Close()
+	})
+
+	t.Run("reconnect rate", func(t *testing.T) {
+		h := NewShardedHub(1)
+		a := NewAdmission(AdmissionPolicy{ReconnectRate: 0.1, ReconnectBurst: 2})
+		for i := 0; i < 2; i++ {
+			sub, err := a.Subscribe(h, "u", SubscribeOptions{})
+			if err != nil {
+				t.Fatalf("Subscribe %d: %v", i, err)
+			}
+			sub.Close()
+		}
+		_, err := a.Subscribe(h, "u", SubscribeOptions{})
+		var rejected *AdmissionError
+		if !errors.As(err, &rejected) || rejected.Reason != AdmitReconnectRate || rejected.RetryAfter <= 0 {
+			t.Fatalf("expected reconnect rate rejection, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,26 @@
+package sse
+
+import "context"
+
+// Broker names accepted by config.
+const (
+	BrokerRedisPubSub  = "redis_pubsub"
+	BrokerRedisStreams = "redis_streams"
+	BrokerNATS         = "nats"
+)
+
+// Broker carries encoded envelopes between service instances. The bridge
+// owns reconnects, buffering and routing; a Broker only moves bytes.
+type Broker interface {
+	// Name identifies the backend in logs and status.
+	Name() string
+	// Publish sends body to every topic.
+	Publish(ctx context.Context, topics []string, body []byte) error
+	// Subscribe passes messages received on topics to handle until ctx is
+	// cancelled (it then returns nil) or the connection fails. ready is
+	// called once the subscription is established, before any message.
+	Subscribe(ctx context.Context, topics []string, ready func(), handle func(body []byte)) error
+	// Close releases broker-side resources owned by this instance. It does
+	// not close the underlying client.
+	Close() error
+}
@@ -0,0 +1,79 @@
+package sse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsBroker is the NATS core backend. Like Redis Pub/Sub it is
+// fire-and-forget; the NATS client buffers publishes while reconnecting.
+type natsBroker struct {
+	conn *nats.Conn
+}
+
+// NewNATSBroker returns a Broker backed by an established NATS connection.
+func NewNATSBroker(conn *nats.Conn) Broker {
+	return &natsBroker{conn: conn}
+}
+
+// natsSubject maps a channel name such as "go-video:notification:sse" to a
+// dot-separated NATS subject.
+func natsSubject(topic string) string {
+	return strings.ReplaceAll(topic, ":", ".")
+}
+
+func (b *natsBroker) Name() string { return BrokerNATS }
+
+func (b *natsBroker) Publish(_ context.Context, topics []string, body []byte) error {
+	for _, t := range topics {
+		if err := b.conn.Publish(natsSubject(t), body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *natsBroker) Subscribe(ctx context.Context, topics []string, ready func(), handle func([]byte)) error {
+	msgs := make(chan *nats.Msg, 1024)
+	for _, t := range topics {
+		sub, err := b.conn.ChanSubscribe(natsSubject(t), msgs)
+		if err != nil {
+			return fmt.Errorf("subscribe subject=%s: %w", natsSubject(t), err)
+		}
+		defer sub.Unsubscribe()
+	}
+	// Make sure the server registered the interest before reporting ready.
+	flushCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	err := b.conn.FlushWithContext(flushCtx)
+	cancel()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("flush subscriptions: %w", err)
+	}
+	ready()
+
+	ticker := time.NewTicker(receiveTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case m := <-msgs:
+			handle(m.Data)
+		case <-ticker.C:
+			// The client reconnects on its own; surface the outage so the
+			// bridge buffers instead of relying on the client buffer.
+			if st := b.conn.Status(); st != nats.CONNECTED {
+				return fmt.Errorf("nats connection %s", st)
+			}
+		}
+	}
+}
+
+func (b *natsBroker) Close() error { return nil }
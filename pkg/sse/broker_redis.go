@@ -0,0 +1,273 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"notification-service/pkg/logger"
+)
+
+// redisPubSubBroker is the fire-and-forget Redis Pub/Sub backend. Messages
+// published while an instance is disconnected are lost for that instance.
+type redisPubSubBroker struct {
+	// client must be a *redis.Client (or compatible type) that supports both
+	// Publish and Subscribe. We intentionally do not use redis.Cmdable here
+	// because Cmdable does not declare Subscribe.
+	client *redis.Client
+}
+
+// NewRedisPubSubBroker returns a Broker backed by Redis Pub/Sub.
+func NewRedisPubSubBroker(client *redis.Client) Broker {
+	return &redisPubSubBroker{client: client}
+}
+
+func (b *redisPubSubBroker) Name() string { return BrokerRedisPubSub }
+
+func (b *redisPubSubBroker) Publish(ctx context.Context, topics []string, body []byte) error {
+	if len(topics) == 1 {
+		return b.client.Publish(ctx, topics[0], body).Err()
+	}
+	_, err := b.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, t := range topics {
+			pipe.Publish(ctx, t, body)
+		}
+		return nil
+	})
+	return err
+}
+
+func (b *redisPubSubBroker) Subscribe(ctx context.Context, topics []string, ready func(), handle func([]byte)) error {
+	pubsub := b.client.Subscribe(ctx, topics...)
+	defer pubsub.Close()
+
+	// Ensure subscription is established before reading messages.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("subscribe channels=%v: %w", topics, err)
+	}
+	ready()
+
+	for {
+		msg, err := pubsub.ReceiveTimeout(ctx, receiveTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				if err := pubsub.Ping(ctx); err != nil {
+					return fmt.Errorf("ping: %w", err)
+				}
+				continue
+			}
+			return err
+		}
+
+		switch m := msg.(type) {
+		case *redis.Message:
+			handle([]byte(m.Payload))
+		case *redis.Subscription, *redis.Pong:
+		}
+	}
+}
+
+func (b *redisPubSubBroker) Close() error { return nil }
+
+const (
+	// streamBodyField is the entry field holding the encoded envelope.
+	streamBodyField = "body"
+	// defaultStreamMaxLen approximately caps each stream.
+	defaultStreamMaxLen = 10000
+	// defaultStreamRetention is how long entries are kept for replay.
+	defaultStreamRetention = time.Hour
+	streamReadCount        = 100
+)
+
+// streamBlock bounds each blocking read so shutdown is not held up by an
+// idle stream; a variable so tests can shorten it.
+var streamBlock = 2 * time.Second
+
+// RedisStreamsOptions configures the Redis Streams broker.
+type RedisStreamsOptions struct {
+	// MaxLen caps each stream approximately; zero uses the default.
+	MaxLen int64
+	// Group names this instance's consumer group. It must be stable across
+	// restarts of the same pod (e.g. the StatefulSet pod name) for missed
+	// entries to be replayed, and unique among running instances; empty
+	// uses the host name.
+	Group string
+	// Retention is how long entries are kept for replay. Streams nobody
+	// publishes to expire after it, and groups whose consumers have been
+	// idle for longer are dropped as orphans of pods that are gone. Zero
+	// uses the default.
+	Retention time.Duration
+}
+
+// redisStreamsBroker is the durable Redis Streams backend. Each instance
+// reads every topic through its own consumer group, so entries published
+// while it was disconnected or restarting, and entries it received but never
+// acknowledged, are replayed once it is back, as long as they are within
+// the retention.
+type redisStreamsBroker struct {
+	client    *redis.Client
+	group     string
+	maxLen    int64
+	retention time.Duration
+}
+
+// NewRedisStreamsBroker returns a Broker backed by Redis Streams.
+func NewRedisStreamsBroker(client *redis.Client, opts RedisStreamsOptions) Broker {
+	if opts.MaxLen <= 0 {
+		opts.MaxLen = defaultStreamMaxLen
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = defaultStreamRetention
+	}
+	if opts.Group == "" {
+		opts.Group = defaultStreamGroup()
+	}
+	return &redisStreamsBroker{client: client, group: opts.Group, maxLen: opts.MaxLen, retention: opts.Retention}
+}
+
+func defaultStreamGroup() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return instanceID
+	}
+	return host
+}
+
+func (b *redisStreamsBroker) Name() string { return BrokerRedisStreams }
+
+func (b *redisStreamsBroker) Publish(ctx context.Context, topics []string, body []byte) error {
+	// Entry IDs start with their millisecond timestamp.
+	minID := strconv.FormatInt(time.Now().Add(-b.retention).UnixMilli(), 10)
+	_, err := b.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, t := range topics {
+			pipe.XAdd(ctx, &redis.XAddArgs{
+				Stream: t,
+				MaxLen: b.maxLen,
+				Approx: true,
+				Values: map[string]interface{}{streamBodyField: body},
+			})
+			pipe.XTrimMinIDApprox(ctx, t, minID, 0)
+			pipe.Expire(ctx, t, b.retention)
+		}
+		return nil
+	})
+	return err
+}
+
+func (b *redisStreamsBroker) Subscribe(ctx context.Context, topics []string, ready func(), handle func([]byte)) error {
+	for _, t := range topics {
+		if err := b.pruneGroups(ctx, t); err != nil {
+			logger.Warnf("sse: prune stale consumer groups failed stream=%s error=%v", t, err)
+		}
+		// "$" only applies when the group is new; an existing group resumes
+		// from its last delivered entry.
+		err := b.client.XGroupCreateMkStream(ctx, t, b.group, "$").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return fmt.Errorf("create consumer group stream=%s: %w", t, err)
+		}
+	}
+	ready()
+
+	// Replay this consumer's pending entries first, then read new ones.
+	pending := true
+	for {
+		ids := make([]string, len(topics))
+		for i := range ids {
+			ids[i] = ">"
+			if pending {
+				ids[i] = "0"
+			}
+		}
+		streams, err := b.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    b.group,
+			Consumer: b.group,
+			Streams:  append(append([]string(nil), topics...), ids...),
+			Count:    streamReadCount,
+			Block:    streamBlock,
+		}).Result()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			return fmt.Errorf("read streams=%v: %w", topics, err)
+		}
+
+		n := 0
+		for _, s := range streams {
+			if len(s.Messages) == 0 {
+				continue
+			}
+			acks := make([]string, 0, len(s.Messages))
+			for _, m := range s.Messages {
+				if body, ok := m.Values[streamBodyField].(string); ok {
+					handle([]byte(body))
+				}
+				acks = append(acks, m.ID)
+			}
+			n += len(acks)
+			if err := b.client.XAck(ctx, s.Stream, b.group, acks...).Err(); err != nil {
+				return fmt.Errorf("ack stream=%s: %w", s.Stream, err)
+			}
+		}
+		if pending && n == 0 {
+			pending = false
+		}
+	}
+}
+
+// pruneGroups drops the consumer groups of stream whose consumers have all
+// been idle for longer than the retention, left behind by pods that were
+// rescheduled or scaled away. Groups without consumers are kept: they may
+// have just been created by an instance that is starting up.
+func (b *redisStreamsBroker) pruneGroups(ctx context.Context, stream string) error {
+	groups, err := b.client.XInfoGroups(ctx, stream).Result()
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "ERR no such key") {
+			return nil
+		}
+		return err
+	}
+	for _, g := range groups {
+		if g.Name == b.group || g.Consumers == 0 {
+			continue
+		}
+		consumers, err := b.client.XInfoConsumers(ctx, stream, g.Name).Result()
+		if err != nil {
+			return err
+		}
+		stale := true
+		for _, c := range consumers {
+			if c.Idle < b.retention {
+				stale = false
+				break
+			}
+		}
+		if !stale {
+			continue
+		}
+		if err := b.client.XGroupDestroy(ctx, stream, g.Name).Err(); err != nil {
+			return err
+		}
+		logger.Infof("sse: dropped stale consumer group stream=%s group=%s", stream, g.Name)
+	}
+	return nil
+}
+
+// Close keeps the consumer groups so a restarted instance with the same
+// group resumes where it stopped; groups of instances that do not come back
+// are pruned by the others.
+func (b *redisStreamsBroker) Close() error { return nil }
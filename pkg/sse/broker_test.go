@@ -0,0 +1,161 @@
+package sse
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/redis/go-redis/v9"
+)
+
+// runBroker subscribes broker to topics in the background and returns the
+// received bodies plus a function that stops the subscription.
+func runBroker(t *testing.T, broker Broker, topics ...string) (<-chan string, func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	ready := make(chan struct{})
+	bodies := make(chan string, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- broker.Subscribe(ctx, topics, func() { close(ready) }, func(body []byte) {
+			bodies <- string(body)
+		})
+	}()
+	select {
+	case <-ready:
+	case err := <-done:
+		t.Fatalf("subscribe: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("subscription not ready")
+	}
+	return bodies, func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Fatalf("subscribe returned %v after cancel", err)
+		}
+	}
+}
+
+func receiveBody(t *testing.T, bodies <-chan string) string {
+	t.Helper()
+	select {
+	case body := <-bodies:
+		return body
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for message")
+		return ""
+	}
+}
+
+func TestRedisStreamsBrokerReplaysAfterReconnect(t *testing.T) {
+	defer func(block time.Duration) { streamBlock = block }(streamBlock)
+	streamBlock = 50 * time.Millisecond
+
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	defer client.Close()
+	opts := RedisStreamsOptions{Group: "notification-0", Retention: time.Hour}
+	broker := NewRedisStreamsBroker(client, opts)
+	ctx := context.Background()
+
+	bodies, stop := runBroker(t, broker, "test:streams")
+	if err := broker.Publish(ctx, []string{"test:streams"}, []byte("first")); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if got := receiveBody(t, bodies); got != "first" {
+		t.Fatalf("got %q", got)
+	}
+	stop()
+	if err := broker.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	// Entries published while the instance restarts are kept for its group.
+	if err := broker.Publish(ctx, []string{"test:streams"}, []byte("missed")); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	restarted := NewRedisStreamsBroker(client, opts)
+	bodies, stop = runBroker(t, restarted, "test:streams")
+	if got := receiveBody(t, bodies); got != "missed" {
+		t.Fatalf("got %q, want replayed entry", got)
+	}
+	stop()
+}
+
+func TestRedisStreamsBrokerPrunesStaleGroups(t *testing.T) {
+	defer func(block time.Duration) { streamBlock = block }(streamBlock)
+	streamBlock = 50 * time.Millisecond
+
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	// A pod that went away left its group behind. miniredis only tracks a
+	// consumer's idle time on XCLAIM, so its last read is recorded that way.
+	now := time.Now()
+	srv.SetTime(now)
+	if err := client.XGroupCreateMkStream(ctx, "test:streams", "notification-gone", "$").Err(); err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	id := client.XAdd(ctx, &redis.XAddArgs{Stream: "test:streams", Values: map[string]interface{}{streamBodyField: "x"}}).Val()
+	if err := client.XReadGroup(ctx, &redis.XReadGroupArgs{Group: "notification-gone", Consumer: "notification-gone", Streams: []string{"test:streams", ">"}}).Err(); err != nil {
+		t.Fatalf("read group: %v", err)
+	}
+	if err := client.XClaim(ctx, &redis.XClaimArgs{Stream: "test:streams", Group: "notification-gone", Consumer: "notification-gone", Messages: []string{id}}).Err(); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	opts := RedisStreamsOptions{Group: "notification-0", Retention: time.Minute}
+	_, stop := runBroker(t, NewRedisStreamsBroker(client, opts), "test:streams")
+	stop()
+	if groups := client.XInfoGroups(ctx, "test:streams").Val(); len(groups) != 2 {
+		t.Fatalf("groups = %+v, recently active group must be kept", groups)
+	}
+
+	// Once its consumers have been idle past the retention, the next
+	// instance to subscribe drops it.
+	srv.SetTime(now.Add(2 * time.Minute))
+	_, stop = runBroker(t, NewRedisStreamsBroker(client, opts), "test:streams")
+	stop()
+	groups := client.XInfoGroups(ctx, "test:streams").Val()
+	if len(groups) != 1 || groups[0].Name != "notification-0" {
+		t.Fatalf("groups = %+v, want only the live instance's", groups)
+	}
+}
+
+func TestNATSBrokerDeliversThroughBridge(t *testing.T) {
+	ns, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1})
+	if err != nil {
+		t.Fatalf("nats server: %v", err)
+	}
+	go ns.Start()
+	defer ns.Shutdown()
+	if !ns.ReadyForConnections(5 * time.Second) {
+		t.Fatalf("nats server not ready")
+	}
+	conn, err := nats.Connect(ns.ClientURL())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer conn.Close()
+
+	InitBridge(NewNATSBroker(conn), BridgeOptions{Channel: "test:nats"})
+	defer CloseBridge()
+	waitForBridgeState(t, BridgeConnected)
+
+	events := DefaultHub().Subscribe("u-nats", SubscribeOptions{})
+	defer events.Close()
+
+	PublishNotification("u-nats", Event{Type: "notification.created"})
+	if ev := receive(t, events); ev.Type != "notification.created" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	// Lag is measured from the envelope's SentAt.
+	if st, _ := CurrentBridgeStatus(); st.Broker != BrokerNATS || st.Broadcast != 1 || st.LastLagMs <= 0 || st.AvgLagMs != st.LastLagMs {
+		t.Fatalf("unexpected status %+v", st)
+	}
+}
@@ -0,0 +1,103 @@
+package sse
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// ReconnectEventType is the last event of a stream closed by a drain; the
+// client should reconnect after the retry hint sent with it.
+const ReconnectEventType = "reconnect"
+
+// AdmitDraining rejects streams while the instance drains.
+const AdmitDraining = "draining"
+
+// ReconnectError is reported by Subscription.Err for streams closed by a
+// drain. Retry is the reconnect delay to hint to the client.
+type ReconnectError struct {
+	Retry time.Duration
+}
+
+func (e *ReconnectError) Error() string {
+	return "sse: instance draining, reconnect in " + e.Retry.String()
+}
+
+// DrainOptions configures Drain.
+type DrainOptions struct {
+	// Waves splits the connections into this many batches so clients do not
+	// all reconnect at once; zero closes them in one wave.
+	Waves        int
+	WaveInterval time.Duration
+	// RetryMin and RetryMax bound the jittered reconnect hint.
+	RetryMin time.Duration
+	RetryMax time.Duration
+}
+
+var draining atomic.Bool
+
+// Draining reports whether Drain has started; new streams are rejected.
+func Draining() bool {
+	return draining.Load()
+}
+
+// StartDraining makes Admit reject new streams without closing existing
+// ones, e.g. while a load balancer deregisters the instance.
+func StartDraining() {
+	draining.Store(true)
+}
+
+// Drain rejects new streams and closes the existing ones of h in waves,
+// asking each client to reconnect after a jittered delay. When ctx ends, the
+// remaining streams are closed at once. It returns when h has no
+// connections left, or ctx's error if they did not go away in time.
+func Drain(ctx context.Context, h Hub, opts DrainOptions) error {
+	StartDraining()
+
+	conns := h.Connections()
+	rand.Shuffle(len(conns), func(i, j int) { conns[i], conns[j] = conns[j], conns[i] })
+	waves := max(opts.Waves, 1)
+	size := (len(conns) + waves - 1) / waves
+
+	for start := 0; start < len(conns); start += size {
+		if start > 0 && ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(opts.WaveInterval):
+			}
+		}
+		end := min(start+size, len(conns))
+		if ctx.Err() != nil {
+			end = len(conns)
+		}
+		for _, c := range conns[start:end] {
+			h.Disconnect(c.UserUUID, c.ID, &ReconnectError{Retry: jitter(opts.RetryMin, opts.RetryMax)})
+		}
+		if end == len(conns) {
+			break
+		}
+	}
+	// Streams admitted while the listing above was taken.
+	for _, c := range h.Connections() {
+		h.Disconnect(c.UserUUID, c.ID, &ReconnectError{Retry: jitter(opts.RetryMin, opts.RetryMax)})
+	}
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for h.ConnectionCount() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+func jitter(lo, hi time.Duration) time.Duration {
+	if hi <= lo {
+		return lo
+	}
+	return lo + rand.N(hi-lo)
+}
@@ -0,0 +1,54 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDrainClosesStreamsInWaves(t *testing.T) {
+	defer draining.Store(false)
+	defer func(h Hub) { SetDefaultHub(h) }(DefaultHub())
+	h := NewShardedHub(1)
+	SetDefaultHub(h)
+
+	var subs []*Subscription
+	for _, user := range []string{"a", "a", "b", "c"} {
+		sub, err := Admit(user, SubscribeOptions{})
+		if err != nil {
+			t.Fatalf("Admit: %v", err)
+		}
+		defer sub.Close()
+		subs = append(subs, sub)
+	}
+	h.Publish("b", Event{Type: "queued"})
+
+	opts := DrainOptions{Waves: 2, WaveInterval: 20 * time.Millisecond, RetryMin: time.Second, RetryMax: 3 * time.Second}
+	start := time.Now()
+	if err := Drain(context.Background(), h, opts); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < opts.WaveInterval {
+		t.Fatalf("drained in one wave after %s", elapsed)
+	}
+
+	for _, sub := range subs {
+		var reconnect *ReconnectError
+		if !errors.As(sub.Err(), &reconnect) {
+			t.Fatalf("Err = %v", sub.Err())
+		}
+		if reconnect.Retry < opts.RetryMin || reconnect.Retry > opts.RetryMax {
+			t.Fatalf("retry hint %s out of range", reconnect.Retry)
+		}
+	}
+	// Events queued before the drain can still be written out.
+	if evs := subs[2].Drain(nil); len(evs) != 1 || evs[0].Type != "queued" {
+		t.Fatalf("expected queued event after drain, got %+v", evs)
+	}
+
+	_, err := Admit("d", SubscribeOptions{})
+	if admissionReason(err) != AdmitDraining {
+		t.Fatalf("expected draining rejection, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package sse
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"notification-service/pkg/logger"
+)
+
+// Frame returns ev encoded as an SSE frame: an optional "retry:" line, the
+// "event:" and "data:" lines and the blank line ending the frame. Events
+// published through a hub are encoded once and share the frame across
+// subscribers.
+func (ev Event) Frame() ([]byte, error) {
+	if ev.frame != nil {
+		return ev.frame, nil
+	}
+	return encodeFrame(ev)
+}
+
+func encodeFrame(ev Event) ([]byte, error) {
+	data, err := json.Marshal(ev.Data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(ev.Type) + len(data) + 32)
+	if ev.Retry > 0 {
+		buf.WriteString("retry: ")
+		buf.WriteString(strconv.FormatInt(ev.Retry.Milliseconds(), 10))
+		buf.WriteByte('\n')
+	}
+	buf.WriteString("event: ")
+	buf.WriteString(ev.Type)
+	buf.WriteString("\ndata: ")
+	buf.Write(data)
+	buf.WriteString("\n\n")
+	return buf.Bytes(), nil
+}
+
+// encoded returns ev with its frame attached, or false if the data cannot
+// be encoded, in which case no subscriber could write it either.
+func (ev Event) encoded() (Event, bool) {
+	if ev.frame != nil {
+		return ev, true
+	}
+	frame, err := encodeFrame(ev)
+	if err != nil {
+		logger.Errorf("sse: dropping event that cannot be encoded type=%s error=%v", ev.Type, err)
+		return ev, false
+	}
+	ev.frame = frame
+	return ev, true
+}
@@ -1,73 +1,153 @@
 package sse
 
-import "sync"
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
 
 // Event represents a server-sent notification event payload.
 // Type is used as SSE "event:" name, Data is an arbitrary JSON-serialisable body.
 type Event struct {
 	Type string      `json:"type"`
 	Data interface{} `json:"data,omitempty"`
+	// Coalesce marks state-style events (e.g. an unread count) where only
+	// the latest value matters: a queued event of the same type is replaced
+	// instead of queueing another one.
+	Coalesce bool `json:"-"`
+	// Retry, when set, is sent as the "retry:" reconnect hint with the event.
+	Retry time.Duration `json:"-"`
+
+	// frame is the encoded SSE frame, shared by all subscribers.
+	frame []byte
 }
 
 // Hub keeps in-memory SSE subscribers grouped by user.
-// This hub is process-local and intended for single-instance or dev environments.
-// Internally it uses sync.Map to minimise lock contention at high scale.
-type Hub struct {
-	// subscribers maps user UUID -> *sync.Map representing a set of channels.
-	subscribers sync.Map // map[string]*sync.Map
+// A hub is process-local; the bridge fans events in from other instances.
+type Hub interface {
+	// Subscribe registers a user-specific subscriber; the caller must Close
+	// the subscription on disconnect.
+	Subscribe(userUUID string, opts SubscribeOptions) *Subscription
+	// Publish queues an event for all subscribers of the given user. The
+	// event is encoded once, see Event.Frame. It never blocks; slow
+	// subscribers are handled by their overflow policy.
+	Publish(userUUID string, ev Event)
+	// SetListener installs l as the subscription listener; nil removes it.
+	SetListener(l SubscriptionListener)
+	// SubscriberCounts returns the number of live subscribers per user;
+	// users without subscribers are not listed.
+	SubscriberCounts() map[string]int
+	// ConnectionCount returns the number of live subscribers on this
+	// instance.
+	ConnectionCount() int
+	// UserConnectionCount returns the number of live subscribers of a user.
+	UserConnectionCount(userUUID string) int
+	// Connections lists live subscribers, oldest first.
+	Connections() []ConnectionInfo
+	// UserConnections lists the live subscribers of a user, oldest first.
+	UserConnections(userUUID string) []ConnectionInfo
+	// Disconnect ends the user's subscription with the given connection ID;
+	// its Err reports reason. It reports whether the subscription was found.
+	Disconnect(userUUID string, connID uint64, reason error) bool
+	// Stats returns cumulative delivery counters.
+	Stats() HubStats
+}
+
+// Hub implementations selectable by config.
+const (
+	HubSharded = "sharded"
+	HubSyncMap = "syncmap"
+)
+
+// NewHub constructs the default Hub implementation.
+func NewHub() Hub {
+	return NewShardedHub(0)
+}
+
+// NewHubOfKind constructs the Hub implementation named by kind; empty
+// selects the default.
+func NewHubOfKind(kind string) (Hub, error) {
+	switch kind {
+	case "", HubSharded:
+		return NewShardedHub(0), nil
+	case HubSyncMap:
+		return NewSyncMapHub(), nil
+	default:
+		return nil, fmt.Errorf("sse: unknown hub %q", kind)
+	}
 }
 
-// NewHub constructs a Hub.
-func NewHub() *Hub {
-	return &Hub{}
+type hubHolder struct {
+	h Hub
 }
 
-var defaultHub = NewHub()
+var defaultHub atomic.Pointer[hubHolder]
+
+func init() {
+	defaultHub.Store(&hubHolder{h: NewHub()})
+}
 
 // DefaultHub exposes the process-global hub.
-func DefaultHub() *Hub {
-	return defaultHub
+func DefaultHub() Hub {
+	return defaultHub.Load().h
 }
 
-// Subscribe registers a user-specific subscriber and returns a channel
-// plus an unsubscribe function that should be called on disconnect.
-func (h *Hub) Subscribe(userUUID string) (<-chan Event, func()) {
-	ch := make(chan Event, 16)
+// SetDefaultHub replaces the process-global hub. It must be called at
+// startup, before any subscriber or bridge uses the hub.
+func SetDefaultHub(h Hub) {
+	defaultHub.Store(&hubHolder{h: h})
+}
 
-	// Lazily create the inner set for this user.
-	v, _ := h.subscribers.LoadOrStore(userUUID, &sync.Map{})
-	inner := v.(*sync.Map)
-	inner.Store(ch, struct{}{})
+// SubscriptionListener observes subscriber churn, e.g. to maintain a
+// cross-instance presence directory. Calls are made synchronously from
+// Subscribe and unsubscribe, once per subscriber, so implementations must
+// not block.
+type SubscriptionListener interface {
+	Subscribed(userUUID string)
+	Unsubscribed(userUUID string)
+}
 
-	unsubscribe := func() {
-		inner.Delete(ch)
-		close(ch)
-		// Note: we intentionally do not remove empty inner maps from
-		// the outer subscribers map to keep implementation simple.
-	}
+type listenerHolder struct {
+	l SubscriptionListener
+}
+
+// hubBase implements SetListener, Stats and ConnectionCount for the hubs.
+type hubBase struct {
+	hubStats
+	listener    atomic.Pointer[listenerHolder]
+	connections atomic.Int64
+}
 
-	return ch, unsubscribe
+// ConnectionCount returns the number of live subscribers.
+func (h *hubBase) ConnectionCount() int {
+	return int(h.connections.Load())
 }
 
-// Publish sends an event to all subscribers of the given user.
-// Slow consumers are skipped to avoid blocking producer code.
-func (h *Hub) Publish(userUUID string, ev Event) {
-	v, ok := h.subscribers.Load(userUUID)
-	if !ok {
+// SetListener installs l as the subscription listener; nil removes it.
+func (h *hubBase) SetListener(l SubscriptionListener) {
+	if l == nil {
+		h.listener.Store(nil)
 		return
 	}
-	inner := v.(*sync.Map)
-
-	inner.Range(func(key, _ interface{}) bool {
-		ch, ok := key.(chan Event)
-		if !ok {
-			return true
-		}
-		select {
-		case ch <- ev:
-		default:
-			// drop if subscriber is slow
-		}
-		return true
-	})
+	h.listener.Store(&listenerHolder{l: l})
+}
+
+func (h *hubBase) subscribed(userUUID string) {
+	h.connections.Add(1)
+	if lh := h.listener.Load(); lh != nil {
+		lh.l.Subscribed(userUUID)
+	}
+}
+
+func (h *hubBase) unsubscribed(userUUID string) {
+	h.connections.Add(-1)
+	if lh := h.listener.Load(); lh != nil {
+		lh.l.Unsubscribed(userUUID)
+	}
+}
+
+func sortConnections(conns []ConnectionInfo) []ConnectionInfo {
+	sort.Slice(conns, func(i, j int) bool { return conns[i].ID < conns[j].ID })
+	return conns
 }
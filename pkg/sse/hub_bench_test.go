@@ -8,11 +8,11 @@ import (
 	"testing"
 )
 
-// benchHub is the minimal interface implemented by both Hub and SyncMapHub.
-type benchHub interface {
-	Subscribe(userUUID string) (<-chan Event, func())
-	Publish(userUUID string, ev Event)
-}
+// Hubs under comparison: a single-mutex hub (ShardedHub with one shard),
+// the default ShardedHub and the lock-free SyncMapHub.
+func newMutexHub() Hub   { return NewShardedHub(1) }
+func newShardedHub() Hub { return NewShardedHub(0) }
+func newSyncMapHub() Hub { return NewSyncMapHub() }
 
 // parseSubscribersEnv reads HUB_BENCH_SUBSCRIBERS environment variable to
 // allow overriding the number of pre-created subscribers in heavy benchmarks.
@@ -38,38 +38,41 @@ func parseUsersEnv(defaultValue int) int {
 
 // --- Subscribe/Unsubscribe churn benchmarks ---
 
-func benchmarkSubUnsub(b *testing.B, newHub func() benchHub) {
+func benchmarkSubUnsub(b *testing.B, newHub func() Hub) {
 	h := newHub()
 	const user = "user-1"
 
 	b.ResetTimer()
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
-			_, unsubscribe := h.Subscribe(user)
-			unsubscribe()
+			h.Subscribe(user, SubscribeOptions{}).Close()
 		}
 	})
 }
 
 func BenchmarkMutexHub_SubUnsub(b *testing.B) {
-	benchmarkSubUnsub(b, func() benchHub { return NewHub() })
+	benchmarkSubUnsub(b, newMutexHub)
+}
+
+func BenchmarkShardedHub_SubUnsub(b *testing.B) {
+	benchmarkSubUnsub(b, newShardedHub)
 }
 
 func BenchmarkSyncMapHub_SubUnsub(b *testing.B) {
-	benchmarkSubUnsub(b, func() benchHub { return NewSyncMapHub() })
+	benchmarkSubUnsub(b, newSyncMapHub)
 }
 
 // --- Publish with many subscribers (single user, steady-state) ---
 
-func benchmarkPublishSteadyState(b *testing.B, newHub func() benchHub, defaultSubscribers int) {
+func benchmarkPublishSteadyState(b *testing.B, newHub func() Hub, defaultSubscribers int) {
 	h := newHub()
 	const user = "user-steady"
 
 	subs := parseSubscribersEnv(defaultSubscribers)
 	for i := 0; i < subs; i++ {
-		// We ignore the channel and unsubscribe here; this benchmark focuses
-		// on Publish cost with a fixed subscriber set.
-		_, _ = h.Subscribe(user)
+		// Nobody drains or closes these; this benchmark focuses on Publish
+		// cost with a fixed subscriber set, mostly on the overflow path.
+		_ = h.Subscribe(user, SubscribeOptions{})
 	}
 
 	ev := Event{Type: "bench"}
@@ -86,16 +89,20 @@ func benchmarkPublishSteadyState(b *testing.B, newHub func() benchHub, defaultSu
 // HUB_BENCH_SUBSCRIBERS=1000000 go test ./pkg/sse -run=^$ -bench=BenchmarkMutexHub_PublishSteady -benchmem
 
 func BenchmarkMutexHub_PublishSteady(b *testing.B) {
-	benchmarkPublishSteadyState(b, func() benchHub { return NewHub() }, 100_000)
+	benchmarkPublishSteadyState(b, newMutexHub, 100_000)
+}
+
+func BenchmarkShardedHub_PublishSteady(b *testing.B) {
+	benchmarkPublishSteadyState(b, newShardedHub, 100_000)
 }
 
 func BenchmarkSyncMapHub_PublishSteady(b *testing.B) {
-	benchmarkPublishSteadyState(b, func() benchHub { return NewSyncMapHub() }, 100_000)
+	benchmarkPublishSteadyState(b, newSyncMapHub, 100_000)
 }
 
 // --- Churn: subscribe + publish + unsubscribe in a tight loop ---
 
-func benchmarkChurn(b *testing.B, newHub func() benchHub) {
+func benchmarkChurn(b *testing.B, newHub func() Hub) {
 	h := newHub()
 	const user = "user-churn"
 	ev := Event{Type: "bench"}
@@ -103,26 +110,30 @@ func benchmarkChurn(b *testing.B, newHub func() benchHub) {
 	b.ResetTimer()
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
-			_, unsubscribe := h.Subscribe(user)
+			sub := h.Subscribe(user, SubscribeOptions{})
 			h.Publish(user, ev)
-			unsubscribe()
+			sub.Close()
 		}
 	})
 }
 
 func BenchmarkMutexHub_Churn(b *testing.B) {
-	benchmarkChurn(b, func() benchHub { return NewHub() })
+	benchmarkChurn(b, newMutexHub)
+}
+
+func BenchmarkShardedHub_Churn(b *testing.B) {
+	benchmarkChurn(b, newShardedHub)
 }
 
 func BenchmarkSyncMapHub_Churn(b *testing.B) {
-	benchmarkChurn(b, func() benchHub { return NewSyncMapHub() })
+	benchmarkChurn(b, newSyncMapHub)
 }
 
 // --- Many users, single connection per user ---
 
 // benchmarkManyUsersSingleConnPublish 模拟“10 万用户各自 1 条连接”的场景：
 // 预先为 N 个 userUUID 建立订阅，每次 Publish 只对其中一个用户推送事件。
-func benchmarkManyUsersSingleConnPublish(b *testing.B, newHub func() benchHub, defaultUsers int) {
+func benchmarkManyUsersSingleConnPublish(b *testing.B, newHub func() Hub, defaultUsers int) {
 	h := newHub()
 
 	users := parseUsersEnv(defaultUsers)
@@ -130,7 +141,7 @@ func benchmarkManyUsersSingleConnPublish(b *testing.B, newHub func() benchHub, d
 	for i := 0; i < users; i++ {
 		uid := fmt.Sprintf("user-%d", i)
 		userIDs[i] = uid
-		_, _ = h.Subscribe(uid)
+		_ = h.Subscribe(uid, SubscribeOptions{})
 	}
 
 	ev := Event{Type: "bench"}
@@ -152,9 +163,13 @@ func benchmarkManyUsersSingleConnPublish(b *testing.B, newHub func() benchHub, d
 // HUB_BENCH_USERS=100000 go test ./pkg/sse -run=^$ -bench=BenchmarkMutexHub_ManyUsersSingleConn -benchmem
 
 func BenchmarkMutexHub_ManyUsersSingleConn(b *testing.B) {
-	benchmarkManyUsersSingleConnPublish(b, func() benchHub { return NewHub() }, 100_000)
+	benchmarkManyUsersSingleConnPublish(b, newMutexHub, 100_000)
+}
+
+func BenchmarkShardedHub_ManyUsersSingleConn(b *testing.B) {
+	benchmarkManyUsersSingleConnPublish(b, newShardedHub, 100_000)
 }
 
 func BenchmarkSyncMapHub_ManyUsersSingleConn(b *testing.B) {
-	benchmarkManyUsersSingleConnPublish(b, func() benchHub { return NewSyncMapHub() }, 100_000)
+	benchmarkManyUsersSingleConnPublish(b, newSyncMapHub, 100_000)
 }
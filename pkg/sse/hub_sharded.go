@@ -0,0 +1,165 @@
+package sse
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// defaultHubShards is the shard count used by NewShardedHub(0).
+const defaultHubShards = 64
+
+// ShardedHub spreads users over mutex-guarded shards. Publish takes a read
+// lock on one shard, so it scales with cores as long as hot users land on
+// different shards; empty users are removed on the last unsubscribe, so
+// memory follows the number of connected users. A single shard is a plain
+// mutex hub.
+type ShardedHub struct {
+	hubBase
+	shards []hubShard
+}
+
+type hubShard struct {
+	mu    sync.RWMutex
+	users map[string]map[*Subscription]struct{}
+}
+
+// NewShardedHub constructs a ShardedHub; shards <= 0 uses the default.
+func NewShardedHub(shards int) *ShardedHub {
+	if shards <= 0 {
+		shards = defaultHubShards
+	}
+	h := &ShardedHub{shards: make([]hubShard, shards)}
+	for i := range h.shards {
+		h.shards[i].users = make(map[string]map[*Subscription]struct{})
+	}
+	return h
+}
+
+func (h *ShardedHub) shard(userUUID string) *hubShard {
+	if len(h.shards) == 1 {
+		return &h.shards[0]
+	}
+	f := fnv.New32a()
+	_, _ = f.Write([]byte(userUUID))
+	return &h.shards[f.Sum32()%uint32(len(h.shards))]
+}
+
+// Subscribe implements Hub.
+func (h *ShardedHub) Subscribe(userUUID string, opts SubscribeOptions) *Subscription {
+	sub := newSubscription(userUUID, opts, &h.hubStats)
+	sh := h.shard(userUUID)
+
+	// release must be set before the subscription becomes visible to
+	// Publish, which may disconnect it.
+	sub.release = func() {
+		sh.mu.Lock()
+		if set := sh.users[userUUID]; set != nil {
+			delete(set, sub)
+			if len(set) == 0 {
+				delete(sh.users, userUUID)
+			}
+		}
+		sh.mu.Unlock()
+		h.unsubscribed(userUUID)
+	}
+
+	sh.mu.Lock()
+	set, ok := sh.users[userUUID]
+	if !ok {
+		set = make(map[*Subscription]struct{})
+		sh.users[userUUID] = set
+	}
+	set[sub] = struct{}{}
+	sh.mu.Unlock()
+	h.subscribed(userUUID)
+	return sub
+}
+
+// Publish implements Hub.
+func (h *ShardedHub) Publish(userUUID string, ev Event) {
+	sh := h.shard(userUUID)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	set := sh.users[userUUID]
+	if len(set) == 0 {
+		h.noSubscribers.Add(1)
+		return
+	}
+	ev, ok := ev.encoded()
+	if !ok {
+		return
+	}
+	h.published.Add(1)
+	for sub := range set {
+		sub.send(ev)
+	}
+}
+
+// SubscriberCounts implements Hub.
+func (h *ShardedHub) SubscriberCounts() map[string]int {
+	counts := make(map[string]int)
+	for i := range h.shards {
+		sh := &h.shards[i]
+		sh.mu.RLock()
+		for user, set := range sh.users {
+			counts[user] = len(set)
+		}
+		sh.mu.RUnlock()
+	}
+	return counts
+}
+
+// UserConnectionCount implements Hub.
+func (h *ShardedHub) UserConnectionCount(userUUID string) int {
+	sh := h.shard(userUUID)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	return len(sh.users[userUUID])
+}
+
+// Connections implements Hub.
+func (h *ShardedHub) Connections() []ConnectionInfo {
+	conns := make([]ConnectionInfo, 0, h.ConnectionCount())
+	for i := range h.shards {
+		sh := &h.shards[i]
+		sh.mu.RLock()
+		for _, set := range sh.users {
+			for sub := range set {
+				conns = append(conns, sub.Info())
+			}
+		}
+		sh.mu.RUnlock()
+	}
+	return sortConnections(conns)
+}
+
+// UserConnections implements Hub.
+func (h *ShardedHub) UserConnections(userUUID string) []ConnectionInfo {
+	sh := h.shard(userUUID)
+	sh.mu.RLock()
+	conns := make([]ConnectionInfo, 0, len(sh.users[userUUID]))
+	for sub := range sh.users[userUUID] {
+		conns = append(conns, sub.Info())
+	}
+	sh.mu.RUnlock()
+	return sortConnections(conns)
+}
+
+// Disconnect implements Hub.
+func (h *ShardedHub) Disconnect(userUUID string, connID uint64, reason error) bool {
+	sh := h.shard(userUUID)
+	var found *Subscription
+	sh.mu.RLock()
+	for sub := range sh.users[userUUID] {
+		if sub.info.ID == connID {
+			found = sub
+			break
+		}
+	}
+	sh.mu.RUnlock()
+	if found == nil {
+		return false
+	}
+	found.disconnect(reason)
+	return true
+}
@@ -1,8 +1,165 @@
 package sse
 
-// NewSyncMapHub is kept for benchmark/backwards compatibility.
-// It currently returns the default Hub implementation, which uses
-// sync.Map internally for subscriber storage.
-func NewSyncMapHub() *Hub {
-	return NewHub()
+import "sync"
+
+// SyncMapHub keeps subscribers in nested sync.Maps. Publish never takes a
+// lock, which suits read-heavy fan-out to a few very hot users, at the cost
+// of more memory per subscriber and slower churn. A user is evicted from the
+// outer map when its last subscriber leaves.
+type SyncMapHub struct {
+	hubBase
+	// users maps user UUID -> *syncMapUser.
+	users sync.Map
+}
+
+// syncMapUser is the subscriber set of one user. mu serialises churn so the
+// set can be evicted exactly when it becomes empty; Publish only reads subs.
+type syncMapUser struct {
+	mu      sync.Mutex
+	n       int
+	evicted bool
+	subs    sync.Map // map[*Subscription]struct{}
+}
+
+// NewSyncMapHub constructs a SyncMapHub.
+func NewSyncMapHub() *SyncMapHub {
+	return &SyncMapHub{}
+}
+
+// Subscribe implements Hub.
+func (h *SyncMapHub) Subscribe(userUUID string, opts SubscribeOptions) *Subscription {
+	sub := newSubscription(userUUID, opts, &h.hubStats)
+
+	for {
+		v, _ := h.users.LoadOrStore(userUUID, &syncMapUser{})
+		u := v.(*syncMapUser)
+		u.mu.Lock()
+		if u.evicted {
+			// Lost a race with the last unsubscribe; retry with a fresh set.
+			u.mu.Unlock()
+			continue
+		}
+		// release must be set before the subscription becomes visible to
+		// Publish, which may disconnect it.
+		sub.release = func() { h.release(userUUID, u, sub) }
+		u.subs.Store(sub, struct{}{})
+		u.n++
+		u.mu.Unlock()
+		break
+	}
+	h.subscribed(userUUID)
+	return sub
+}
+
+func (h *SyncMapHub) release(userUUID string, u *syncMapUser, sub *Subscription) {
+	u.mu.Lock()
+	if _, ok := u.subs.LoadAndDelete(sub); ok {
+		u.n--
+		if u.n == 0 {
+			u.evicted = true
+			h.users.CompareAndDelete(userUUID, u)
+		}
+	}
+	u.mu.Unlock()
+	h.unsubscribed(userUUID)
+}
+
+func (h *SyncMapHub) user(userUUID string) *syncMapUser {
+	v, ok := h.users.Load(userUUID)
+	if !ok {
+		return nil
+	}
+	return v.(*syncMapUser)
+}
+
+// Publish implements Hub.
+func (h *SyncMapHub) Publish(userUUID string, ev Event) {
+	u := h.user(userUUID)
+	if u == nil {
+		h.noSubscribers.Add(1)
+		return
+	}
+	ev, ok := ev.encoded()
+	if !ok {
+		return
+	}
+	h.published.Add(1)
+	u.subs.Range(func(key, _ interface{}) bool {
+		key.(*Subscription).send(ev)
+		return true
+	})
+}
+
+// SubscriberCounts implements Hub.
+func (h *SyncMapHub) SubscriberCounts() map[string]int {
+	counts := make(map[string]int)
+	h.users.Range(func(key, value interface{}) bool {
+		u := value.(*syncMapUser)
+		u.mu.Lock()
+		if u.n > 0 {
+			counts[key.(string)] = u.n
+		}
+		u.mu.Unlock()
+		return true
+	})
+	return counts
+}
+
+// UserConnectionCount implements Hub.
+func (h *SyncMapHub) UserConnectionCount(userUUID string) int {
+	u := h.user(userUUID)
+	if u == nil {
+		return 0
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.n
+}
+
+// Connections implements Hub.
+func (h *SyncMapHub) Connections() []ConnectionInfo {
+	conns := make([]ConnectionInfo, 0, h.ConnectionCount())
+	h.users.Range(func(_, value interface{}) bool {
+		conns = value.(*syncMapUser).appendInfo(conns)
+		return true
+	})
+	return sortConnections(conns)
+}
+
+// UserConnections implements Hub.
+func (h *SyncMapHub) UserConnections(userUUID string) []ConnectionInfo {
+	var conns []ConnectionInfo
+	if u := h.user(userUUID); u != nil {
+		conns = u.appendInfo(conns)
+	}
+	return sortConnections(conns)
+}
+
+// Disconnect implements Hub.
+func (h *SyncMapHub) Disconnect(userUUID string, connID uint64, reason error) bool {
+	u := h.user(userUUID)
+	if u == nil {
+		return false
+	}
+	var found *Subscription
+	u.subs.Range(func(key, _ interface{}) bool {
+		if sub := key.(*Subscription); sub.info.ID == connID {
+			found = sub
+			return false
+		}
+		return true
+	})
+	if found == nil {
+		return false
+	}
+	found.disconnect(reason)
+	return true
+}
+
+func (u *syncMapUser) appendInfo(dst []ConnectionInfo) []ConnectionInfo {
+	u.subs.Range(func(key, _ interface{}) bool {
+		dst = append(dst, key.(*Subscription).Info())
+		return true
+	})
+	return dst
 }
@@ -0,0 +1,255 @@
+package sse
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type countingListener struct {
+	mu     sync.Mutex
+	active map[string]int
+}
+
+func (l *countingListener) Subscribed(user string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active[user]++
+}
+
+func (l *countingListener) Unsubscribed(user string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active[user]--
+}
+
+func TestHubImplementations(t *testing.T) {
+	for _, kind := range []string{HubSharded, HubSyncMap} {
+		t.Run(kind, func(t *testing.T) {
+			h, err := NewHubOfKind(kind)
+			if err != nil {
+				t.Fatalf("NewHubOfKind: %v", err)
+			}
+			l := &countingListener{active: map[string]int{}}
+			h.SetListener(l)
+
+			a1 := h.Subscribe("a", SubscribeOptions{})
+			a2 := h.Subscribe("a", SubscribeOptions{RemoteAddr: "10.0.0.2", UserAgent: "test"})
+			b := h.Subscribe("b", SubscribeOptions{})
+
+			h.Publish("a", Event{Type: "x"})
+			for _, sub := range []*Subscription{a1, a2} {
+				if ev := receive(t, sub); ev.Type != "x" {
+					t.Fatalf("unexpected event %+v", ev)
+				}
+			}
+			if evs := b.Drain(nil); len(evs) != 0 {
+				t.Fatalf("user b received %+v", evs)
+			}
+
+			if counts := h.SubscriberCounts(); counts["a"] != 2 || counts["b"] != 1 {
+				t.Fatalf("unexpected counts %v", counts)
+			}
+			if n := h.ConnectionCount(); n != 3 {
+				t.Fatalf("ConnectionCount = %d", n)
+			}
+			if n := h.UserConnectionCount("a"); n != 2 {
+				t.Fatalf("UserConnectionCount = %d", n)
+			}
+			conns := h.UserConnections("a")
+			if len(conns) != 2 || conns[0].ID != a1.Info().ID || conns[1].RemoteAddr != "10.0.0.2" || conns[1].UserAgent != "test" {
+				t.Fatalf("unexpected connections %+v", conns)
+			}
+			if all := h.Connections(); len(all) != 3 || all[2].UserUUID != "b" {
+				t.Fatalf("unexpected connections %+v", all)
+			}
+
+			a1.Close()
+			a1.Close() // idempotent
+			select {
+			case <-a1.Done():
+			default:
+				t.Fatalf("subscription not done after Close")
+			}
+			h.Publish("a", Event{Type: "y"})
+			if ev := receive(t, a2); ev.Type != "y" {
+				t.Fatalf("unexpected event %+v", ev)
+			}
+
+			a2.Close()
+			b.Close()
+			if counts := h.SubscriberCounts(); len(counts) != 0 {
+				t.Fatalf("expected no subscribers, got %v", counts)
+			}
+			if n := h.ConnectionCount(); n != 0 || len(h.Connections()) != 0 {
+				t.Fatalf("ConnectionCount = %d after unsubscribe", n)
+			}
+			if l.active["a"] != 0 || l.active["b"] != 0 {
+				t.Fatalf("listener out of balance %v", l.active)
+			}
+		})
+	}
+	if _, err := NewHubOfKind("bogus"); err == nil {
+		t.Fatalf("expected error for unknown hub")
+	}
+}
+
+func TestHubPublishRacingUnsubscribe(t *testing.T) {
+	for _, h := range []Hub{NewShardedHub(0), NewSyncMapHub()} {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 500; j++ {
+					h.Subscribe("race", SubscribeOptions{}).Close()
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 500; j++ {
+					h.Publish("race", Event{Type: "x"})
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func TestSubscriptionCoalescesStateEvents(t *testing.T) {
+	h := NewShardedHub(1)
+	sub := h.Subscribe("u", SubscribeOptions{Overflow: OverflowPolicy{Capacity: 4}})
+	defer sub.Close()
+
+	h.Publish("u", Event{Type: "notification.created", Data: 1})
+	for i := 1; i <= 10; i++ {
+		h.Publish("u", Event{Type: "notification.updated", Data: i, Coalesce: true})
+	}
+
+	evs := sub.Drain(nil)
+	if len(evs) != 2 || evs[1].Data != 10 {
+		t.Fatalf("expected created plus latest update, got %+v", evs)
+	}
+	if st := h.Stats(); st.Coalesced != 9 || st.Dropped != 0 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+}
+
+func TestSubscriptionOverflowPolicies(t *testing.T) {
+	t.Run("resync", func(t *testing.T) {
+		h := NewShardedHub(1)
+		sub := h.Subscribe("u", SubscribeOptions{Overflow: OverflowPolicy{Capacity: 2, Action: OverflowResync}})
+		defer sub.Close()
+		for i := 0; i < 5; i++ {
+			h.Publish("u", Event{Type: "notification.created", Data: i})
+		}
+		evs := sub.Drain(nil)
+		if len(evs) != 1 || evs[0].Type != ResyncEventType {
+			t.Fatalf("expected a single resync event, got %+v", evs)
+		}
+		// After the client drained the resync, delivery resumes.
+		h.Publish("u", Event{Type: "notification.created", Data: 5})
+		if evs := sub.Drain(nil); len(evs) != 1 || evs[0].Data != 5 {
+			t.Fatalf("expected delivery to resume, got %+v", evs)
+		}
+		if st := h.Stats(); st.Resyncs != 1 || st.Dropped != 5 || st.Overflows != 3 {
+			t.Fatalf("unexpected stats %+v", st)
+		}
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		h := NewShardedHub(1)
+		sub := h.Subscribe("u", SubscribeOptions{Overflow: OverflowPolicy{Capacity: 2, Action: OverflowDrop}})
+		defer sub.Close()
+		for i := 0; i < 5; i++ {
+			h.Publish("u", Event{Type: "notification.created", Data: i})
+		}
+		if evs := sub.Drain(nil); len(evs) != 2 || evs[1].Data != 1 {
+			t.Fatalf("expected the first two events, got %+v", evs)
+		}
+		if st := h.Stats(); st.Dropped != 3 {
+			t.Fatalf("unexpected stats %+v", st)
+		}
+	})
+
+	t.Run("disconnect stuck consumer", func(t *testing.T) {
+		h := NewShardedHub(1)
+		sub := h.Subscribe("u", SubscribeOptions{Overflow: OverflowPolicy{Capacity: 1, DisconnectAfter: time.Millisecond}})
+		h.Publish("u", Event{Type: "a"})
+		time.Sleep(5 * time.Millisecond)
+		h.Publish("u", Event{Type: "b"})
+
+		select {
+		case <-sub.Done():
+		case <-time.After(time.Second):
+			t.Fatalf("stuck subscriber not disconnected")
+		}
+		if !errors.Is(sub.Err(), ErrSlowConsumer) {
+			t.Fatalf("Err = %v", sub.Err())
+		}
+		deadline := time.Now().Add(time.Second)
+		for len(h.SubscriberCounts()) != 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("disconnected subscriber still registered")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		if st := h.Stats(); st.SlowDisconnects != 1 {
+			t.Fatalf("unexpected stats %+v", st)
+		}
+	})
+}
+
+func TestSyncMapHubEvictsEmptyUsers(t *testing.T) {
+	h := NewSyncMapHub()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				h.Subscribe("churn", SubscribeOptions{}).Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, user := range []string{"a", "b", "c"} {
+		h.Subscribe(user, SubscribeOptions{}).Close()
+	}
+	n := 0
+	h.users.Range(func(_, _ interface{}) bool {
+		n++
+		return true
+	})
+	if n != 0 || h.ConnectionCount() != 0 {
+		t.Fatalf("expected empty hub, got %d users and %d connections", n, h.ConnectionCount())
+	}
+}
+
+func TestPublishSharesEncodedFrame(t *testing.T) {
+	h := NewShardedHub(1)
+	a := h.Subscribe("u", SubscribeOptions{})
+	defer a.Close()
+	b := h.Subscribe("u", SubscribeOptions{})
+	defer b.Close()
+
+	h.Publish("u", Event{Type: "notification.created", Data: map[string]int{"unread_count": 3}})
+	fa, err := receive(t, a).Frame()
+	if err != nil {
+		t.Fatalf("Frame: %v", err)
+	}
+	fb, _ := receive(t, b).Frame()
+	if want := "event: notification.created\ndata: {\"unread_count\":3}\n\n"; string(fa) != want {
+		t.Fatalf("frame = %q, want %q", fa, want)
+	}
+	if &fa[0] != &fb[0] {
+		t.Fatalf("subscribers got separately encoded frames")
+	}
+
+	frame, _ := Event{Type: ReconnectEventType, Retry: 1500 * time.Millisecond}.Frame()
+	if want := "retry: 1500\nevent: reconnect\ndata: null\n\n"; string(frame) != want {
+		t.Fatalf("frame = %q, want %q", frame, want)
+	}
+}
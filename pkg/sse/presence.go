@@ -0,0 +1,208 @@
+package sse
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"notification-service/pkg/logger"
+)
+
+const (
+	presenceKeyPrefix = "go-video:notification:presence:"
+	// presenceBatchSize bounds the number of users refreshed per pipeline.
+	presenceBatchSize = 500
+	// presenceOpQueue bounds pending add/remove operations; overflow is
+	// repaired by the next heartbeat.
+	presenceOpQueue = 4096
+)
+
+// presenceDirectory records in Redis which instances hold SSE connections
+// for which users. Each user has a sorted set of instance IDs scored by
+// expiry, refreshed by a heartbeat so crashed instances age out.
+type presenceDirectory struct {
+	client *redis.Client
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	local map[string]int // user UUID -> local connection count
+
+	ops    chan presenceOp
+	resync chan struct{}
+}
+
+type presenceOp struct {
+	userUUID string
+	add      bool
+}
+
+func newPresenceDirectory(client *redis.Client, ttl time.Duration) *presenceDirectory {
+	return &presenceDirectory{
+		client: client,
+		ttl:    ttl,
+		local:  make(map[string]int),
+		ops:    make(chan presenceOp, presenceOpQueue),
+		resync: make(chan struct{}, 1),
+	}
+}
+
+func presenceKey(userUUID string) string {
+	return presenceKeyPrefix + userUUID
+}
+
+// Subscribed implements SubscriptionListener.
+func (p *presenceDirectory) Subscribed(userUUID string) {
+	p.mu.Lock()
+	p.local[userUUID]++
+	first := p.local[userUUID] == 1
+	p.mu.Unlock()
+	if first {
+		p.enqueue(presenceOp{userUUID: userUUID, add: true})
+	}
+}
+
+// Unsubscribed implements SubscriptionListener.
+func (p *presenceDirectory) Unsubscribed(userUUID string) {
+	p.mu.Lock()
+	p.local[userUUID]--
+	last := p.local[userUUID] <= 0
+	if last {
+		delete(p.local, userUUID)
+	}
+	p.mu.Unlock()
+	if last {
+		p.enqueue(presenceOp{userUUID: userUUID, add: false})
+	}
+}
+
+func (p *presenceDirectory) enqueue(op presenceOp) {
+	select {
+	case p.ops <- op:
+	default:
+	}
+}
+
+// requestRefresh asks run to re-register every local user, e.g. after the
+// bridge reconnected to a Redis that may have lost its data.
+func (p *presenceDirectory) requestRefresh() {
+	select {
+	case p.resync <- struct{}{}:
+	default:
+	}
+}
+
+// seed registers users that connected before the directory was attached.
+func (p *presenceDirectory) seed(counts map[string]int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for user, n := range counts {
+		if p.local[user] == 0 && n > 0 {
+			p.local[user] = n
+		}
+	}
+}
+
+// run applies queued operations and refreshes all local users every ttl/3
+// until ctx is cancelled, then withdraws this instance's presence.
+func (p *presenceDirectory) run(ctx context.Context) {
+	ticker := time.NewTicker(p.ttl / 3)
+	defer ticker.Stop()
+
+	p.refresh(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			p.withdraw()
+			return
+		case op := <-p.ops:
+			opCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+			var err error
+			if op.add {
+				err = p.add(opCtx, []string{op.userUUID})
+			} else {
+				err = p.client.ZRem(opCtx, presenceKey(op.userUUID), instanceID).Err()
+			}
+			cancel()
+			if err != nil && ctx.Err() == nil {
+				logger.Warnf("sse: update presence failed user_uuid=%s add=%t error=%v", op.userUUID, op.add, err)
+			}
+		case <-ticker.C:
+			p.refresh(ctx)
+		case <-p.resync:
+			p.refresh(ctx)
+		}
+	}
+}
+
+func (p *presenceDirectory) localUsers() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	users := make([]string, 0, len(p.local))
+	for user := range p.local {
+		users = append(users, user)
+	}
+	return users
+}
+
+func (p *presenceDirectory) refresh(ctx context.Context) {
+	users := p.localUsers()
+	for start := 0; start < len(users); start += presenceBatchSize {
+		end := start + presenceBatchSize
+		if end > len(users) {
+			end = len(users)
+		}
+		if err := p.add(ctx, users[start:end]); err != nil {
+			if ctx.Err() == nil {
+				logger.Warnf("sse: refresh presence failed users=%d error=%v", end-start, err)
+			}
+			return
+		}
+	}
+}
+
+// add marks this instance as hosting users until now+ttl.
+func (p *presenceDirectory) add(ctx context.Context, users []string) error {
+	now := time.Now()
+	expiry := float64(now.Add(p.ttl).UnixMilli())
+	_, err := p.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, user := range users {
+			key := presenceKey(user)
+			pipe.ZAdd(ctx, key, redis.Z{Score: expiry, Member: instanceID})
+			pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.UnixMilli(), 10))
+			pipe.Expire(ctx, key, p.ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+// withdraw removes this instance from every user it was hosting so other
+// instances stop routing to it right away instead of waiting for the TTL.
+func (p *presenceDirectory) withdraw() {
+	users := p.localUsers()
+	if len(users) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err := p.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, user := range users {
+			pipe.ZRem(ctx, presenceKey(user), instanceID)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Warnf("sse: withdraw presence failed users=%d error=%v", len(users), err)
+	}
+}
+
+// lookup returns the instances currently hosting userUUID.
+func (p *presenceDirectory) lookup(ctx context.Context, userUUID string) ([]string, error) {
+	return p.client.ZRangeByScore(ctx, presenceKey(userUUID), &redis.ZRangeBy{
+		Min: strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Max: "+inf",
+	}).Result()
+}
@@ -2,133 +2,670 @@ package sse
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 
 	"notification-service/pkg/logger"
+	"notification-service/pkg/tracing"
 )
 
 const (
-	// defaultRedisChannel is the shared channel used for cross-instance notification events.
-	defaultRedisChannel = "go-video:notification:sse"
+	// defaultChannel is the shared channel used for cross-instance notification events.
+	defaultChannel = "go-video:notification:sse"
+
+	// defaultBufferSize bounds how many envelopes are kept while the broker
+	// is unreachable; they are flushed once the subscriber reconnects.
+	defaultBufferSize = 1024
+
+	// defaultPresenceTTL is how long a presence entry survives without a
+	// heartbeat from its instance.
+	defaultPresenceTTL = 30 * time.Second
 )
 
-// redisEnvelope is the message shape stored in Redis Pub/Sub.
+// Reconnect tuning; variables so tests can shorten them.
+var (
+	minReconnectBackoff = 500 * time.Millisecond
+	maxReconnectBackoff = 30 * time.Second
+	// receiveTimeout is how long the subscriber waits for a message before
+	// checking the connection to detect half-open connections.
+	receiveTimeout = 30 * time.Second
+)
+
+// ErrBridgeBufferFull is returned when the broker is unreachable and the
+// bridge buffer cannot hold more events.
+var ErrBridgeBufferFull = errors.New("sse: bridge buffer full")
+
+// ErrBridgeUnavailable is returned by DeliverNotification while the bridge
+// subscriber is not connected to the broker, or while a configured bridge
+// is not attached yet.
+var ErrBridgeUnavailable = errors.New("sse: bridge not connected")
+
+// instanceID identifies this process in envelopes so it can skip events it
+// already delivered locally.
+var instanceID = newInstanceID()
+
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return host + "-" + uuid.NewString()[:8]
+}
+
+// envelope is the message shape carried by the broker.
 // It wraps the user-specific SSE Event so all instances can fan it back
 // into their local in-memory Hub.
-type redisEnvelope struct {
+type envelope struct {
 	UserUUID string      `json:"user_uuid"`
 	Type     string      `json:"type"`
 	Data     interface{} `json:"data,omitempty"`
-	SentAt   time.Time   `json:"sent_at"`
+	Coalesce bool        `json:"coalesce,omitempty"`
+	// SentAt is stamped each time the envelope is put on the broker, so a
+	// buffered envelope carries its flush time rather than its creation time.
+	SentAt time.Time `json:"sent_at"`
+	// Origin is the instance that published the envelope. When
+	// LocalDelivered is set, Origin already fanned it into its own Hub.
+	Origin         string `json:"origin,omitempty"`
+	LocalDelivered bool   `json:"local_delivered,omitempty"`
+	// Trace is the W3C trace context of the publish span, so delivery on
+	// the receiving instance joins the producer's trace.
+	Trace map[string]string `json:"trace,omitempty"`
+}
+
+// BridgeOptions configures the cross-instance bridge.
+type BridgeOptions struct {
+	// Channel is the shared broadcast channel; empty uses the default.
+	Channel string
+	// TargetedRouting publishes each event only to the per-instance channels
+	// of instances that currently host the user, based on a presence
+	// directory in Redis. Events are broadcast on Channel when presence is
+	// unavailable.
+	TargetedRouting bool
+	// Presence holds the presence directory; it is required for targeted
+	// routing whatever the broker.
+	Presence *redis.Client
+	// Signing authenticates envelopes between instances.
+	Signing SigningOptions
+	// PresenceTTL bounds how long a crashed instance stays in the presence
+	// directory; zero uses the default.
+	PresenceTTL time.Duration
+}
+
+// instanceChannel returns the channel that only instance id subscribes to.
+func instanceChannel(shared, id string) string {
+	return shared + ":" + id
 }
 
-// redisPubSubBridge connects the local in-process Hub with a Redis Pub/Sub channel.
-// It lets any instance publish a notification that will be fanned out to all
-// other instances, while keeping adapters bound only to the Hub abstraction.
-type redisPubSubBridge struct {
-	// client must be a *redis.Client (or compatible type) that supports both
-	// Publish and Subscribe. We intentionally do not use redis.Cmdable here
-	// because Cmdable does not declare Subscribe.
-	client  *redis.Client
+// BridgeState describes the broker subscriber connection.
+type BridgeState int32
+
+const (
+	BridgeDisconnected BridgeState = iota
+	BridgeConnecting
+	BridgeConnected
+)
+
+// String returns a human-readable state name.
+func (s BridgeState) String() string {
+	switch s {
+	case BridgeConnecting:
+		return "connecting"
+	case BridgeConnected:
+		return "connected"
+	default:
+		return "disconnected"
+	}
+}
+
+// BridgeStatus is a snapshot of the bridge health.
+type BridgeStatus struct {
+	State   string `json:"state"`
+	Broker  string `json:"broker"`
+	Channel string `json:"channel"`
+	// InstanceChannel is set when targeted routing is enabled.
+	InstanceChannel string    `json:"instance_channel,omitempty"`
+	InstanceID      string    `json:"instance_id"`
+	ConnectedSince  time.Time `json:"connected_since,omitempty"`
+	LastError       string    `json:"last_error,omitempty"`
+	LastErrorAt     time.Time `json:"last_error_at,omitempty"`
+	LastMessageAt   time.Time `json:"last_message_at,omitempty"`
+	// LastLagMs is how long the last accepted envelope took from SentAt to
+	// this instance and AvgLagMs its moving average; both include clock
+	// skew between instances.
+	LastLagMs  float64 `json:"last_lag_ms"`
+	AvgLagMs   float64 `json:"avg_lag_ms"`
+	Reconnects int64   `json:"reconnects"`
+	Buffered   int     `json:"buffered"`
+	Dropped    int64   `json:"dropped"`
+	// PublishErrors counts failed broker publishes, including flushes.
+	PublishErrors int64 `json:"publish_errors"`
+	// Routed counts events published to per-instance channels, Broadcast
+	// those sent on the shared channel and Skipped those not published
+	// because no instance hosts the user.
+	Routed    int64 `json:"routed"`
+	Broadcast int64 `json:"broadcast"`
+	Skipped   int64 `json:"skipped"`
+	// Rejected counts incoming messages dropped per reason (Reject*).
+	Rejected map[string]int64 `json:"rejected,omitempty"`
+}
+
+// bridge connects the local in-process Hub with a message broker.
+// It lets any instance publish a notification that will be fanned out to
+// the other instances, while keeping adapters bound only to the Hub abstraction.
+type bridge struct {
+	broker  Broker
 	channel string
+	// presence is nil when targeted routing is disabled.
+	presence        *presenceDirectory
+	instanceChannel string
+	// signer is nil when envelopes are not signed.
+	signer *envelopeSigner
+
+	mu     sync.Mutex
+	state  BridgeState
+	status BridgeStatus
+	// buffer holds envelopes waiting for the broker to come back.
+	buffer []*envelope
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
-var globalBridge *redisPubSubBridge
+var (
+	globalBridge   *bridge
+	globalBridgeMu sync.RWMutex
 
-// InitRedisPubSub wires the global Hub to a Redis Pub/Sub channel.
-// It should be called once during service startup after the Redis client
-// has been initialised. If redis is unavailable, the service still works
-// in single-instance mode via the in-memory Hub.
-func InitRedisPubSub(client *redis.Client, channel string) {
+	// bridgeExpected is set when a broker is configured, so events are not
+	// delivered locally only before the bridge attaches.
+	bridgeExpected atomic.Bool
+)
+
+// SetBridgeExpected declares whether this instance is configured with a
+// broker. While it is and no bridge is attached (e.g. Redis was unreachable
+// at startup), DeliverNotification returns ErrBridgeUnavailable instead of
+// reaching only the local Hub.
+func SetBridgeExpected(expected bool) {
+	bridgeExpected.Store(expected)
+}
+
+func currentBridge() *bridge {
+	globalBridgeMu.RLock()
+	defer globalBridgeMu.RUnlock()
+	return globalBridge
+}
+
+// InitRedisPubSub wires the global Hub to Redis Pub/Sub, using the same
+// client for the presence directory.
+func InitRedisPubSub(client *redis.Client, opts BridgeOptions) {
 	if client == nil {
 		return
 	}
-	if channel == "" {
-		channel = defaultRedisChannel
+	if opts.Presence == nil {
+		opts.Presence = client
 	}
+	InitBridge(NewRedisPubSubBroker(client), opts)
+}
 
-	globalBridge = &redisPubSubBridge{
-		client:  client,
-		channel: channel,
+// InitBridge wires the global Hub to broker, replacing any previous bridge.
+// It may be called at startup or later, once the broker becomes reachable.
+// The subscriber reconnects with backoff for as long as the bridge is
+// attached; while disconnected, events are delivered locally and buffered
+// for other instances.
+func InitBridge(broker Broker, opts BridgeOptions) {
+	if broker == nil {
+		return
+	}
+	if opts.Channel == "" {
+		opts.Channel = defaultChannel
+	}
+	if opts.PresenceTTL <= 0 {
+		opts.PresenceTTL = defaultPresenceTTL
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &bridge{
+		broker:  broker,
+		channel: opts.Channel,
+		state:   BridgeConnecting,
+		status: BridgeStatus{
+			Broker:     broker.Name(),
+			Channel:    opts.Channel,
+			InstanceID: instanceID,
+		},
+		signer: newEnvelopeSigner(opts.Signing),
+		cancel: cancel,
+	}
+	if opts.TargetedRouting && opts.Presence == nil {
+		logger.Warnf("sse: targeted routing needs a presence store, broadcasting broker=%s", broker.Name())
+	}
+	if opts.TargetedRouting && opts.Presence != nil {
+		b.presence = newPresenceDirectory(opts.Presence, opts.PresenceTTL)
+		b.instanceChannel = instanceChannel(opts.Channel, instanceID)
+		b.status.InstanceChannel = b.instanceChannel
+	}
+
+	globalBridgeMu.Lock()
+	prev := globalBridge
+	globalBridge = b
+	globalBridgeMu.Unlock()
+	if prev != nil {
+		prev.stop()
 	}
 
-	go globalBridge.runSubscriber()
-	logger.Infof("sse: redis pubsub bridge initialised channel=%s", channel)
+	if b.presence != nil {
+		// Install the listener before taking the snapshot so no subscriber
+		// falls between the two.
+		DefaultHub().SetListener(b.presence)
+		b.presence.seed(DefaultHub().SubscriberCounts())
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			b.presence.run(ctx)
+		}()
+	}
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		b.supervise(ctx)
+	}()
+	logger.Infof("sse: bridge initialised broker=%s channel=%s instance=%s targeted=%t", broker.Name(), opts.Channel, instanceID, b.presence != nil)
+}
+
+// CloseBridge detaches the bridge and stops its subscriber. Events
+// published afterwards are delivered to the local Hub only.
+func CloseBridge() {
+	globalBridgeMu.Lock()
+	b := globalBridge
+	globalBridge = nil
+	globalBridgeMu.Unlock()
+	if b != nil {
+		b.stop()
+	}
+}
+
+// CurrentBridgeStatus reports the bridge health; ok is false when no bridge
+// is attached (single-instance mode or the broker not yet reachable).
+func CurrentBridgeStatus() (status BridgeStatus, ok bool) {
+	b := currentBridge()
+	if b == nil {
+		return BridgeStatus{State: BridgeDisconnected.String(), InstanceID: instanceID}, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status = b.status
+	status.State = b.state.String()
+	status.Buffered = len(b.buffer)
+	if len(b.status.Rejected) > 0 {
+		status.Rejected = make(map[string]int64, len(b.status.Rejected))
+		for reason, n := range b.status.Rejected {
+			status.Rejected[reason] = n
+		}
+	}
+	return status, true
 }
 
-// PublishNotification dispatches an SSE notification event.
-//   - In single-instance/dev mode (no redis bridge), it writes directly to the local Hub.
-//   - In multi-instance mode (redis bridge enabled), it publishes to Redis so that
-//     every instance receives the event and replays it into its own Hub.
+// PublishNotification dispatches an SSE notification event on a best-effort
+// basis.
+//   - In single-instance/dev mode (no bridge), it writes directly to the local Hub.
+//   - In multi-instance mode (bridge attached), it publishes to the broker so that
+//     the instances hosting the user (or all instances, without targeted
+//     routing) receive the event and replay it into their own Hub. While the
+//     broker is unreachable the event is delivered locally and kept in an
+//     in-memory buffer for the other instances, which is lost if the process
+//     exits first.
 func PublishNotification(userUUID string, ev Event) {
 	if userUUID == "" || ev.Type == "" {
 		return
 	}
-
-	if globalBridge != nil {
-		globalBridge.publish(userUUID, ev)
+	b := currentBridge()
+	if b == nil {
+		DefaultHub().Publish(userUUID, ev)
 		return
 	}
+	if err := b.publish(context.Background(), userUUID, ev, true); err != nil {
+		logger.Errorf("sse: publish notification failed user_uuid=%s type=%s error=%v", userUUID, ev.Type, err)
+	}
+}
+
+// DeliverNotification publishes the event for reliable producers (e.g. the
+// outbox relay). Unlike PublishNotification it does not fall back to the
+// local Hub and the in-memory buffer: when a configured bridge is not
+// attached or connected, or the broker publish fails, it returns an error,
+// so the caller keeps the event and retries it.
+func DeliverNotification(ctx context.Context, userUUID string, ev Event) error {
+	if userUUID == "" || ev.Type == "" {
+		return nil
+	}
+
+	if b := currentBridge(); b != nil {
+		return b.publish(ctx, userUUID, ev, false)
+	}
+	if bridgeExpected.Load() {
+		return ErrBridgeUnavailable
+	}
 
-	// Fallback: process-local only.
+	// Single instance without a broker: process-local only.
 	DefaultHub().Publish(userUUID, ev)
+	return nil
 }
 
-// publish sends the event to the shared Redis channel.
-func (b *redisPubSubBridge) publish(userUUID string, ev Event) {
-	env := &redisEnvelope{
+// publish sends the event to the instances hosting the user. If the broker
+// is unreachable and buffer is set, the event is delivered to the local Hub
+// right away and buffered for the other instances; otherwise the failure is
+// returned.
+func (b *bridge) publish(ctx context.Context, userUUID string, ev Event, buffer bool) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "sse.bridge publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String(b.broker.Name()),
+			attribute.String("sse.event_type", ev.Type),
+			attribute.String("user_uuid", userUUID),
+		))
+	defer func() { tracing.End(span, err) }()
+
+	env := &envelope{
 		UserUUID: userUUID,
 		Type:     ev.Type,
 		Data:     ev.Data,
-		SentAt:   time.Now().UTC(),
+		Coalesce: ev.Coalesce,
+		Origin:   instanceID,
+		Trace:    tracing.Inject(ctx),
+	}
+
+	if b.currentState() == BridgeConnected {
+		pubCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		err := b.send(pubCtx, env, false)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		b.count(&b.status.PublishErrors)
+		b.recordError(err)
+		if !buffer {
+			return fmt.Errorf("sse: publish bridge message: %w", err)
+		}
+		logger.Warnf("sse: publish bridge message failed, falling back to local hub broker=%s error=%v", b.broker.Name(), err)
+	} else if !buffer {
+		return ErrBridgeUnavailable
 	}
 
-	body, err := json.Marshal(env)
+	// Degraded mode: local subscribers get the event now; other instances get
+	// it when the buffer is flushed after reconnecting.
+	span.SetAttributes(attribute.Bool("sse.bridge_buffered", true))
+	DefaultHub().Publish(userUUID, ev)
+	env.LocalDelivered = true
+	return b.enqueue(env)
+}
+
+// send signs env and publishes it to every channel that should see the
+// user's event. skipSelf drops this instance from the targets when it
+// already delivered the event locally.
+func (b *bridge) send(ctx context.Context, env *envelope, skipSelf bool) error {
+	channels := b.targets(ctx, env.UserUUID, skipSelf)
+	if len(channels) == 0 {
+		return nil
+	}
+	env.SentAt = time.Now().UTC()
+	body, err := b.signer.seal(env)
 	if err != nil {
-		logger.Errorf("sse: encode redis envelope failed error=%v", err)
-		return
+		return fmt.Errorf("encode bridge envelope: %w", err)
+	}
+	return b.broker.Publish(ctx, channels, body)
+}
+
+// targets resolves the channels for userUUID. Without targeted routing, or
+// when the presence lookup fails, it falls back to the shared channel. An
+// empty result means no instance currently hosts the user.
+func (b *bridge) targets(ctx context.Context, userUUID string, skipSelf bool) []string {
+	if b.presence == nil {
+		b.count(&b.status.Broadcast)
+		return []string{b.channel}
+	}
+	instances, err := b.presence.lookup(ctx, userUUID)
+	if err != nil {
+		logger.Warnf("sse: presence lookup failed, broadcasting user_uuid=%s error=%v", userUUID, err)
+		b.count(&b.status.Broadcast)
+		return []string{b.channel}
+	}
+	channels := make([]string, 0, len(instances))
+	for _, id := range instances {
+		if skipSelf && id == instanceID {
+			continue
+		}
+		channels = append(channels, instanceChannel(b.channel, id))
+	}
+	if len(channels) == 0 {
+		b.count(&b.status.Skipped)
+	} else {
+		b.count(&b.status.Routed)
+	}
+	return channels
+}
+
+func (b *bridge) count(c *int64) {
+	b.mu.Lock()
+	*c++
+	b.mu.Unlock()
+}
+
+func (b *bridge) enqueue(env *envelope) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.buffer) >= defaultBufferSize {
+		b.status.Dropped++
+		return ErrBridgeBufferFull
 	}
+	b.buffer = append(b.buffer, env)
+	return nil
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
+// flush republishes buffered envelopes after the subscriber reconnected.
+// Targets are resolved again, since presence may have changed meanwhile;
+// this instance already delivered them locally.
+func (b *bridge) flush(ctx context.Context) {
+	b.mu.Lock()
+	pending := b.buffer
+	b.buffer = nil
+	b.mu.Unlock()
 
-	if err := b.client.Publish(ctx, b.channel, body).Err(); err != nil {
-		logger.Errorf("sse: publish redis message failed channel=%s error=%v", b.channel, err)
+	for i, env := range pending {
+		if err := b.send(ctx, env, true); err != nil {
+			b.mu.Lock()
+			b.buffer = append(pending[i:], b.buffer...)
+			b.status.PublishErrors++
+			b.mu.Unlock()
+			b.recordError(err)
+			logger.Warnf("sse: flush buffered bridge messages failed remaining=%d error=%v", len(pending)-i, err)
+			return
+		}
+	}
+	if len(pending) > 0 {
+		logger.Infof("sse: flushed buffered bridge messages count=%d", len(pending))
 	}
 }
 
-// runSubscriber listens on the shared Redis channel and forwards events
-// into the local in-memory Hub. This lets SSE streams on any instance
-// receive notifications regardless of where they were produced.
-func (b *redisPubSubBridge) runSubscriber() {
-	ctx := context.Background()
+func (b *bridge) stop() {
+	if b.presence != nil {
+		DefaultHub().SetListener(nil)
+	}
+	b.cancel()
+	b.wg.Wait()
+	if err := b.broker.Close(); err != nil {
+		logger.Warnf("sse: close broker failed broker=%s error=%v", b.broker.Name(), err)
+	}
+}
 
-	pubsub := b.client.Subscribe(ctx, b.channel)
-	defer pubsub.Close()
+func (b *bridge) currentState() BridgeState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
 
-	// Ensure subscription is established before reading messages.
-	if _, err := pubsub.Receive(ctx); err != nil {
-		logger.Errorf("sse: failed to subscribe to redis channel=%s error=%v", b.channel, err)
-		return
+func (b *bridge) setState(s BridgeState) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = s
+	if s == BridgeConnected {
+		b.status.ConnectedSince = time.Now()
 	}
+}
 
-	ch := pubsub.Channel()
-	for msg := range ch {
-		var env redisEnvelope
-		if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
-			logger.Errorf("sse: failed to decode redis message channel=%s error=%v", b.channel, err)
-			continue
+func (b *bridge) recordError(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.status.LastError = err.Error()
+	b.status.LastErrorAt = time.Now()
+}
+
+// supervise keeps the subscriber running, reconnecting with jittered
+// exponential backoff until the bridge is closed.
+func (b *bridge) supervise(ctx context.Context) {
+	backoff := minReconnectBackoff
+	for {
+		b.setState(BridgeConnecting)
+		start := time.Now()
+		err := b.runSubscriber(ctx)
+		if ctx.Err() != nil {
+			b.setState(BridgeDisconnected)
+			return
 		}
-		if env.UserUUID == "" || env.Type == "" {
-			continue
+
+		b.setState(BridgeDisconnected)
+		if err != nil {
+			b.recordError(err)
 		}
-		// Fan-in back to the local hub; adapters stay unaware of redis.
-		DefaultHub().Publish(env.UserUUID, Event{
-			Type: env.Type,
-			Data: env.Data,
-		})
+		b.mu.Lock()
+		b.status.Reconnects++
+		b.mu.Unlock()
+
+		// A connection that stayed healthy for a while starts over from the
+		// minimum backoff.
+		if time.Since(start) > maxReconnectBackoff {
+			backoff = minReconnectBackoff
+		}
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff)))
+		logger.Errorf("sse: bridge subscriber disconnected broker=%s retry_in=%s error=%v", b.broker.Name(), wait, err)
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			b.setState(BridgeDisconnected)
+			return
+		case <-t.C:
+		}
+		if backoff *= 2; backoff > maxReconnectBackoff {
+			backoff = maxReconnectBackoff
+		}
+	}
+}
+
+// runSubscriber listens on the shared channel (and this instance's channel
+// when routing is targeted) and forwards events into the local in-memory
+// Hub. This lets SSE streams on any instance receive notifications
+// regardless of where they were produced. It returns when the connection
+// fails or ctx is cancelled.
+func (b *bridge) runSubscriber(ctx context.Context) error {
+	channels := []string{b.channel}
+	if b.instanceChannel != "" {
+		channels = append(channels, b.instanceChannel)
+	}
+	return b.broker.Subscribe(ctx, channels, func() {
+		b.setState(BridgeConnected)
+		logger.Infof("sse: bridge subscriber connected broker=%s channel=%s", b.broker.Name(), b.channel)
+		if b.presence != nil {
+			// Redis may have restarted and lost the directory.
+			b.presence.requestRefresh()
+		}
+		b.flush(ctx)
+	}, b.handleMessage)
+}
+
+// lagSmoothing weighs each new sample in the moving average lag.
+const lagSmoothing = 0.1
+
+var lagObserver atomic.Pointer[func(time.Duration)]
+
+// SetBridgeLagObserver installs fn to receive the delivery lag of every
+// accepted bridge envelope, e.g. to feed a latency histogram; nil removes
+// it.
+func SetBridgeLagObserver(fn func(time.Duration)) {
+	if fn == nil {
+		lagObserver.Store(nil)
+		return
+	}
+	lagObserver.Store(&fn)
+}
+
+func (b *bridge) recordLag(lag time.Duration) {
+	if fn := lagObserver.Load(); fn != nil {
+		(*fn)(lag)
+	}
+	ms := float64(lag) / float64(time.Millisecond)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.status.AvgLagMs == 0 {
+		b.status.AvgLagMs = ms
+	} else {
+		b.status.AvgLagMs += lagSmoothing * (ms - b.status.AvgLagMs)
+	}
+	b.status.LastLagMs = ms
+}
+
+func (b *bridge) handleMessage(body []byte) {
+	now := time.Now()
+	b.mu.Lock()
+	b.status.LastMessageAt = now
+	b.mu.Unlock()
+
+	env, err := b.signer.open(body, now)
+	if err != nil {
+		reason := rejectionReason(err)
+		b.mu.Lock()
+		if b.status.Rejected == nil {
+			b.status.Rejected = make(map[string]int64)
+		}
+		b.status.Rejected[reason]++
+		b.mu.Unlock()
+		logger.Warnf("sse: rejected bridge message broker=%s reason=%s error=%v", b.broker.Name(), reason, err)
+		return
+	}
+	if !env.SentAt.IsZero() {
+		b.recordLag(now.Sub(env.SentAt))
+	}
+	if env.UserUUID == "" || env.Type == "" {
+		return
+	}
+	if env.LocalDelivered && env.Origin == instanceID {
+		return
+	}
+	// Envelopes from untraced publishers would only add root spans.
+	if len(env.Trace) > 0 {
+		_, span := tracing.Tracer().Start(tracing.Extract(context.Background(), env.Trace), "sse.bridge receive",
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(
+				semconv.MessagingSystemKey.String(b.broker.Name()),
+				attribute.String("sse.event_type", env.Type),
+				attribute.String("sse.origin", env.Origin),
+				attribute.String("user_uuid", env.UserUUID),
+			))
+		defer span.End()
 	}
+	// Fan-in back to the local hub; adapters stay unaware of the broker.
+	DefaultHub().Publish(env.UserUUID, Event{
+		Type:     env.Type,
+		Data:     env.Data,
+		Coalesce: env.Coalesce,
+	})
 }
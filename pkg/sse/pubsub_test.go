@@ -0,0 +1,269 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func waitForBridgeState(t *testing.T, want BridgeState) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if st, _ := CurrentBridgeStatus(); st.State == want.String() {
+			return
+		}
+		if time.Now().After(deadline) {
+			st, _ := CurrentBridgeStatus()
+			t.Fatalf("bridge state = %s, want %s", st.State, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// receive returns the first queued event, discarding any others.
+func receive(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		if evs := sub.Drain(nil); len(evs) > 0 {
+			return evs[0]
+		}
+		select {
+		case <-sub.Ready():
+		case <-timeout:
+			t.Fatalf("timed out waiting for event")
+			return Event{}
+		}
+	}
+}
+
+func TestDeliverNotificationWithoutBridge(t *testing.T) {
+	CloseBridge()
+	events := DefaultHub().Subscribe("u-local", SubscribeOptions{})
+	defer events.Close()
+
+	// Single instance without a broker: delivered locally.
+	if err := DeliverNotification(t.Context(), "u-local", Event{Type: "notification.created"}); err != nil {
+		t.Fatalf("DeliverNotification: %v", err)
+	}
+	if ev := receive(t, events); ev.Type != "notification.created" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+
+	// A broker is configured but not attached yet: other instances would
+	// miss a local-only delivery, so the caller must retry.
+	SetBridgeExpected(true)
+	defer SetBridgeExpected(false)
+	if err := DeliverNotification(t.Context(), "u-local", Event{Type: "notification.updated"}); !errors.Is(err, ErrBridgeUnavailable) {
+		t.Fatalf("DeliverNotification error = %v, want ErrBridgeUnavailable", err)
+	}
+	if evs := events.Drain(nil); len(evs) != 0 {
+		t.Fatalf("unexpected local delivery %+v", evs)
+	}
+}
+
+func TestRedisBridgeReconnectsAndFlushesBuffer(t *testing.T) {
+	defer func(timeout, backoff time.Duration) {
+		receiveTimeout, minReconnectBackoff = timeout, backoff
+	}(receiveTimeout, minReconnectBackoff)
+	receiveTimeout, minReconnectBackoff = 100*time.Millisecond, 20*time.Millisecond
+
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr(), MaxRetries: -1})
+	defer client.Close()
+
+	InitRedisPubSub(client, BridgeOptions{Channel: "test:sse"})
+	defer CloseBridge()
+	waitForBridgeState(t, BridgeConnected)
+
+	events := DefaultHub().Subscribe("u-bridge", SubscribeOptions{})
+	defer events.Close()
+
+	PublishNotification("u-bridge", Event{Type: "notification.created"})
+	if ev := receive(t, events); ev.Type != "notification.created" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+
+	// Redis goes away: local subscribers still get the event immediately and
+	// it is buffered for the other instances.
+	srv.Close()
+	waitForBridgeState(t, BridgeDisconnected)
+
+	PublishNotification("u-bridge", Event{Type: "notification.updated"})
+	if ev := receive(t, events); ev.Type != "notification.updated" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	if st, _ := CurrentBridgeStatus(); st.Buffered != 1 || st.LastError == "" {
+		t.Fatalf("expected one buffered event and a recorded error, got %+v", st)
+	}
+
+	// Reliable producers get the failure instead, so they can retry later;
+	// nothing is delivered or buffered on their behalf.
+	if err := DeliverNotification(t.Context(), "u-bridge", Event{Type: "notification.created"}); !errors.Is(err, ErrBridgeUnavailable) {
+		t.Fatalf("DeliverNotification error = %v, want ErrBridgeUnavailable", err)
+	}
+	if evs := events.Drain(nil); len(evs) != 0 {
+		t.Fatalf("unexpected local delivery %+v", evs)
+	}
+	if st, _ := CurrentBridgeStatus(); st.Buffered != 1 {
+		t.Fatalf("reliable delivery must not be buffered, got %+v", st)
+	}
+
+	// Observe the shared channel like another instance would.
+	if err := srv.Restart(); err != nil {
+		t.Fatalf("restart redis: %v", err)
+	}
+	other := client.Subscribe(t.Context(), "test:sse")
+	defer other.Close()
+	if _, err := other.Receive(t.Context()); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	waitForBridgeState(t, BridgeConnected)
+	msg, err := other.ReceiveMessage(t.Context())
+	if err != nil {
+		t.Fatalf("receive flushed message: %v", err)
+	}
+	if msg.Payload == "" {
+		t.Fatalf("expected flushed envelope")
+	}
+
+	// The origin instance already delivered the event locally and must not
+	// deliver it twice.
+	time.Sleep(100 * time.Millisecond)
+	if evs := events.Drain(nil); len(evs) != 0 {
+		t.Fatalf("unexpected duplicate event %+v", evs)
+	}
+	if st, _ := CurrentBridgeStatus(); st.Buffered != 0 || st.Reconnects == 0 {
+		t.Fatalf("expected drained buffer after reconnect, got %+v", st)
+	}
+}
+
+func TestRedisBridgeRoutesToHostingInstances(t *testing.T) {
+	defer func(timeout time.Duration) { receiveTimeout = timeout }(receiveTimeout)
+	receiveTimeout = 100 * time.Millisecond
+
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	defer client.Close()
+
+	// A subscriber that exists before the bridge attaches is seeded.
+	events := DefaultHub().Subscribe("u-here", SubscribeOptions{})
+	defer events.Close()
+
+	InitRedisPubSub(client, BridgeOptions{Channel: "test:route", TargetedRouting: true})
+	defer CloseBridge()
+	waitForBridgeState(t, BridgeConnected)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		members, _ := srv.ZMembers(presenceKey("u-here"))
+		if len(members) == 1 && members[0] == instanceID {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("presence not registered, members=%v", members)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	shared := client.Subscribe(t.Context(), "test:route")
+	defer shared.Close()
+	if _, err := shared.Receive(t.Context()); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	PublishNotification("u-here", Event{Type: "notification.created"})
+	if ev := receive(t, events); ev.Type != "notification.created" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+
+	// Nobody hosts u-away, so nothing is published at all.
+	PublishNotification("u-away", Event{Type: "notification.created"})
+
+	if msg, err := shared.ReceiveTimeout(t.Context(), 100*time.Millisecond); err == nil {
+		t.Fatalf("unexpected broadcast %+v", msg)
+	}
+	st, _ := CurrentBridgeStatus()
+	if st.Routed != 1 || st.Skipped != 1 || st.Broadcast != 0 {
+		t.Fatalf("unexpected routing counters %+v", st)
+	}
+
+	// The last local subscriber leaving withdraws the presence entry.
+	events.Close()
+	deadline = time.Now().Add(5 * time.Second)
+	for srv.Exists(presenceKey("u-here")) {
+		if time.Now().After(deadline) {
+			t.Fatalf("presence not withdrawn")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRedisBridgeCarriesTraceContext(t *testing.T) {
+	defer func(timeout time.Duration) { receiveTimeout = timeout }(receiveTimeout)
+	receiveTimeout = 100 * time.Millisecond
+
+	recorder := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	defer func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	}()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	defer client.Close()
+
+	InitRedisPubSub(client, BridgeOptions{Channel: "test:trace"})
+	defer CloseBridge()
+	waitForBridgeState(t, BridgeConnected)
+
+	events := DefaultHub().Subscribe("u-trace", SubscribeOptions{})
+	defer events.Close()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	if err := DeliverNotification(ctx, "u-trace", Event{Type: "notification.created"}); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	parent.End()
+	receive(t, events)
+
+	// The receive span ends right after the hub publish.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		spans := map[string]sdktrace.ReadOnlySpan{}
+		for _, s := range recorder.Ended() {
+			spans[s.Name()] = s
+		}
+		pub, okPub := spans["sse.bridge publish"]
+		recv, okRecv := spans["sse.bridge receive"]
+		if okPub && okRecv {
+			if pub.Parent().SpanID() != parent.SpanContext().SpanID() {
+				t.Fatalf("publish span is not a child of the request span")
+			}
+			if recv.SpanContext().TraceID() != parent.SpanContext().TraceID() {
+				t.Fatalf("receive span trace %s, want %s", recv.SpanContext().TraceID(), parent.SpanContext().TraceID())
+			}
+			if recv.Parent().SpanID() != pub.SpanContext().SpanID() || !recv.Parent().IsRemote() {
+				t.Fatalf("receive span should continue the remote publish span, parent=%v", recv.Parent())
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected publish and receive spans, got %v", spans)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
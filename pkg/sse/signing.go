@@ -0,0 +1,195 @@
+package sse
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Rejection reasons reported in BridgeStatus.Rejected.
+const (
+	RejectMalformed    = "malformed"
+	RejectUnsigned     = "unsigned"
+	RejectUnknownKey   = "unknown_key"
+	RejectBadSignature = "bad_signature"
+	RejectStale        = "stale"
+)
+
+const defaultEnvelopeMaxAge = 2 * time.Minute
+
+// SigningKey is a shared HMAC-SHA256 secret. Keys are identified by ID so
+// they can be rotated without a flag day.
+type SigningKey struct {
+	ID     string
+	Secret []byte
+}
+
+// SigningOptions configures envelope authentication. With no keys, envelopes
+// are sent and accepted unsigned.
+//
+// To rotate, deploy the new key after the current one everywhere (verify
+// only), then move it first (sign with it), then remove the old key.
+type SigningOptions struct {
+	// Keys verify incoming envelopes; the first key signs outgoing ones.
+	Keys []SigningKey
+	// MaxAge rejects envelopes whose SentAt is further than this from the
+	// local clock, in either direction; zero uses the default.
+	MaxAge time.Duration
+	// AllowUnsigned accepts unsigned envelopes while signing is being rolled
+	// out across instances.
+	AllowUnsigned bool
+}
+
+// Validate reports keys that cannot be used.
+func (o SigningOptions) Validate() error {
+	seen := make(map[string]bool, len(o.Keys))
+	for i, k := range o.Keys {
+		if k.ID == "" {
+			return fmt.Errorf("sse signing key %d: missing id", i)
+		}
+		if len(k.Secret) == 0 {
+			return fmt.Errorf("sse signing key %q: missing secret", k.ID)
+		}
+		if seen[k.ID] {
+			return fmt.Errorf("sse signing key %q: duplicate id", k.ID)
+		}
+		seen[k.ID] = true
+	}
+	return nil
+}
+
+// signedFrame is the wire format of a signed envelope. The signature covers
+// the exact payload bytes.
+type signedFrame struct {
+	KeyID     string          `json:"kid"`
+	Signature string          `json:"sig"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// envelopeSigner seals and opens envelopes. A nil signer passes envelopes
+// through as plain JSON.
+type envelopeSigner struct {
+	signKey       SigningKey
+	keys          map[string][]byte
+	maxAge        time.Duration
+	allowUnsigned bool
+}
+
+func newEnvelopeSigner(opts SigningOptions) *envelopeSigner {
+	if len(opts.Keys) == 0 {
+		return nil
+	}
+	s := &envelopeSigner{
+		signKey:       opts.Keys[0],
+		keys:          make(map[string][]byte, len(opts.Keys)),
+		maxAge:        opts.MaxAge,
+		allowUnsigned: opts.AllowUnsigned,
+	}
+	if s.maxAge <= 0 {
+		s.maxAge = defaultEnvelopeMaxAge
+	}
+	for _, k := range opts.Keys {
+		s.keys[k.ID] = k.Secret
+	}
+	return s
+}
+
+func mac(secret, payload []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// seal encodes env, signing it when keys are configured.
+func (s *envelopeSigner) seal(env *envelope) ([]byte, error) {
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return payload, nil
+	}
+	return json.Marshal(signedFrame{
+		KeyID:     s.signKey.ID,
+		Signature: base64.StdEncoding.EncodeToString(mac(s.signKey.Secret, payload)),
+		Payload:   payload,
+	})
+}
+
+// envelopeRejection explains why an incoming message was dropped.
+type envelopeRejection struct {
+	reason string
+	err    error
+}
+
+func (r *envelopeRejection) Error() string {
+	if r.err != nil {
+		return r.reason + ": " + r.err.Error()
+	}
+	return r.reason
+}
+
+func reject(reason string, err error) error {
+	return &envelopeRejection{reason: reason, err: err}
+}
+
+// open decodes and authenticates body. Errors are *envelopeRejection.
+func (s *envelopeSigner) open(body []byte, now time.Time) (*envelope, error) {
+	var frame signedFrame
+	if err := json.Unmarshal(body, &frame); err != nil {
+		return nil, reject(RejectMalformed, err)
+	}
+
+	payload := []byte(frame.Payload)
+	if len(payload) == 0 {
+		// Plain envelope from an instance without signing.
+		if s != nil && !s.allowUnsigned {
+			return nil, reject(RejectUnsigned, nil)
+		}
+		payload = body
+	} else {
+		if s == nil {
+			// Signed envelopes are readable without keys; we just cannot
+			// check them.
+			return decodeEnvelope(payload)
+		}
+		secret, ok := s.keys[frame.KeyID]
+		if !ok {
+			return nil, reject(RejectUnknownKey, fmt.Errorf("kid=%q", frame.KeyID))
+		}
+		sig, err := base64.StdEncoding.DecodeString(frame.Signature)
+		if err != nil || !hmac.Equal(sig, mac(secret, payload)) {
+			return nil, reject(RejectBadSignature, fmt.Errorf("kid=%q", frame.KeyID))
+		}
+	}
+
+	env, err := decodeEnvelope(payload)
+	if err != nil || s == nil {
+		return env, err
+	}
+	if age := now.Sub(env.SentAt); age > s.maxAge || age < -s.maxAge {
+		return nil, reject(RejectStale, fmt.Errorf("sent_at=%s", env.SentAt.Format(time.RFC3339)))
+	}
+	return env, nil
+}
+
+func decodeEnvelope(payload []byte) (*envelope, error) {
+	var env envelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		return nil, reject(RejectMalformed, err)
+	}
+	return &env, nil
+}
+
+// rejectionReason extracts the reason from an open error.
+func rejectionReason(err error) string {
+	var r *envelopeRejection
+	if errors.As(err, &r) {
+		return r.reason
+	}
+	return RejectMalformed
+}
@@ -0,0 +1,112 @@
+package sse
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestEnvelopeSignerOpen(t *testing.T) {
+	oldKey := SigningKey{ID: "old", Secret: []byte("old-secret")}
+	newKey := SigningKey{ID: "new", Secret: []byte("new-secret")}
+	now := time.Now()
+	env := &envelope{UserUUID: "u-1", Type: "notification.created", SentAt: now}
+	plain, _ := json.Marshal(env)
+
+	sealWith := func(keys ...SigningKey) []byte {
+		body, err := newEnvelopeSigner(SigningOptions{Keys: keys}).seal(env)
+		if err != nil {
+			t.Fatalf("seal: %v", err)
+		}
+		return body
+	}
+	tampered := func() []byte {
+		var f signedFrame
+		_ = json.Unmarshal(sealWith(newKey), &f)
+		f.Payload = json.RawMessage(`{"user_uuid":"victim","type":"notification.created"}`)
+		body, _ := json.Marshal(f)
+		return body
+	}()
+
+	tests := []struct {
+		name   string
+		opts   SigningOptions
+		body   []byte
+		at     time.Time
+		reason string
+	}{
+		{name: "valid", opts: SigningOptions{Keys: []SigningKey{newKey}}, body: sealWith(newKey), at: now},
+		{name: "signed with previous key during rotation", opts: SigningOptions{Keys: []SigningKey{newKey, oldKey}}, body: sealWith(oldKey), at: now},
+		{name: "unknown key", opts: SigningOptions{Keys: []SigningKey{newKey}}, body: sealWith(oldKey), at: now, reason: RejectUnknownKey},
+		{name: "tampered payload", opts: SigningOptions{Keys: []SigningKey{newKey}}, body: tampered, at: now, reason: RejectBadSignature},
+		{name: "unsigned", opts: SigningOptions{Keys: []SigningKey{newKey}}, body: plain, at: now, reason: RejectUnsigned},
+		{name: "unsigned allowed during rollout", opts: SigningOptions{Keys: []SigningKey{newKey}, AllowUnsigned: true}, body: plain, at: now},
+		{name: "stale", opts: SigningOptions{Keys: []SigningKey{newKey}, MaxAge: time.Minute}, body: sealWith(newKey), at: now.Add(2 * time.Minute), reason: RejectStale},
+		{name: "from the future", opts: SigningOptions{Keys: []SigningKey{newKey}, MaxAge: time.Minute}, body: sealWith(newKey), at: now.Add(-2 * time.Minute), reason: RejectStale},
+		{name: "malformed", opts: SigningOptions{Keys: []SigningKey{newKey}}, body: []byte("{"), at: now, reason: RejectMalformed},
+		{name: "no keys accepts unsigned", body: plain, at: now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newEnvelopeSigner(tt.opts).open(tt.body, tt.at)
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("open: %v", err)
+				}
+				if got.UserUUID != "u-1" {
+					t.Fatalf("unexpected envelope %+v", got)
+				}
+				return
+			}
+			if reason := rejectionReason(err); err == nil || reason != tt.reason {
+				t.Fatalf("open err = %v, want reason %s", err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestSigningOptionsValidate(t *testing.T) {
+	dup := SigningOptions{Keys: []SigningKey{{ID: "a", Secret: []byte("x")}, {ID: "a", Secret: []byte("y")}}}
+	if err := dup.Validate(); err == nil {
+		t.Fatalf("expected duplicate key id error")
+	}
+	if err := (SigningOptions{Keys: []SigningKey{{ID: "a"}}}).Validate(); err == nil {
+		t.Fatalf("expected missing secret error")
+	}
+}
+
+func TestRedisBridgeRejectsForgedEnvelopes(t *testing.T) {
+	defer func(timeout time.Duration) { receiveTimeout = timeout }(receiveTimeout)
+	receiveTimeout = 100 * time.Millisecond
+
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	defer client.Close()
+
+	InitRedisPubSub(client, BridgeOptions{
+		Channel: "test:signed",
+		Signing: SigningOptions{Keys: []SigningKey{{ID: "k1", Secret: []byte("s3cret")}}},
+	})
+	defer CloseBridge()
+	waitForBridgeState(t, BridgeConnected)
+
+	events := DefaultHub().Subscribe("u-signed", SubscribeOptions{})
+	defer events.Close()
+
+	// Anyone with PUBLISH access to the channel.
+	forged := `{"user_uuid":"u-signed","type":"notification.created","sent_at":"` + time.Now().UTC().Format(time.RFC3339Nano) + `"}`
+	if err := client.Publish(t.Context(), "test:signed", forged).Err(); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	PublishNotification("u-signed", Event{Type: "notification.updated"})
+
+	if ev := receive(t, events); ev.Type != "notification.updated" {
+		t.Fatalf("forged event delivered: %+v", ev)
+	}
+	if st, _ := CurrentBridgeStatus(); st.Rejected[RejectUnsigned] != 1 {
+		t.Fatalf("expected one unsigned rejection, got %+v", st.Rejected)
+	}
+}
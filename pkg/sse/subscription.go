@@ -0,0 +1,328 @@
+package sse
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ResyncEventType is sent to a subscriber whose backlog was discarded; the
+// client should reload its state (unread count, list) instead of relying on
+// the events it missed.
+const ResyncEventType = "resync"
+
+// ErrSlowConsumer is reported by Subscription.Err when the hub disconnected
+// a subscriber that stopped draining its queue.
+var ErrSlowConsumer = errors.New("sse: slow consumer disconnected")
+
+// OverflowAction decides what happens when a subscriber's queue is full.
+type OverflowAction int
+
+const (
+	// OverflowResync discards the backlog and queues a single resync event.
+	OverflowResync OverflowAction = iota
+	// OverflowDrop drops the incoming event.
+	OverflowDrop
+)
+
+// ParseOverflowAction maps a config value to an OverflowAction.
+func ParseOverflowAction(s string) (OverflowAction, bool) {
+	switch s {
+	case "", "resync":
+		return OverflowResync, true
+	case "drop":
+		return OverflowDrop, true
+	default:
+		return OverflowResync, false
+	}
+}
+
+// OverflowPolicy bounds a subscriber's queue. Coalescable events replace a
+// queued event of the same type regardless of the policy.
+type OverflowPolicy struct {
+	// Capacity is the queue length; zero uses the default.
+	Capacity int
+	Action   OverflowAction
+	// DisconnectAfter disconnects a subscriber that overflows again this long
+	// after it last drained its queue; zero never disconnects.
+	DisconnectAfter time.Duration
+}
+
+const defaultQueueCapacity = 16
+
+var defaultOverflowPolicy atomic.Pointer[OverflowPolicy]
+
+func init() {
+	defaultOverflowPolicy.Store(&OverflowPolicy{
+		Capacity:        defaultQueueCapacity,
+		Action:          OverflowResync,
+		DisconnectAfter: time.Minute,
+	})
+}
+
+// SetDefaultOverflowPolicy sets the policy used by subscribers that do not
+// specify one.
+func SetDefaultOverflowPolicy(p OverflowPolicy) {
+	if p.Capacity <= 0 {
+		p.Capacity = defaultQueueCapacity
+	}
+	defaultOverflowPolicy.Store(&p)
+}
+
+// SubscribeOptions configures a single subscriber.
+type SubscribeOptions struct {
+	// Overflow overrides the default overflow policy when Capacity is set.
+	Overflow OverflowPolicy
+	// RemoteAddr and UserAgent describe the client for connection listings.
+	RemoteAddr string
+	UserAgent  string
+}
+
+// ConnectionInfo describes one live subscription.
+type ConnectionInfo struct {
+	ID          uint64    `json:"id"`
+	UserUUID    string    `json:"user_uuid"`
+	ConnectedAt time.Time `json:"connected_at"`
+	RemoteAddr  string    `json:"remote_addr,omitempty"`
+	UserAgent   string    `json:"user_agent,omitempty"`
+	// Queued and Capacity describe the event queue when the info was
+	// taken; LastDrain is when the stream last took events from it.
+	Queued    int       `json:"queued"`
+	Capacity  int       `json:"capacity"`
+	LastDrain time.Time `json:"last_drain"`
+}
+
+// subscriptionSeq numbers subscriptions within the process.
+var subscriptionSeq atomic.Uint64
+
+// HubStats are cumulative delivery counters of a hub.
+type HubStats struct {
+	// Published counts publishes that found local subscribers and
+	// NoSubscribers those that found none; Delivered/Published is the
+	// average fan-out.
+	Published     int64 `json:"published"`
+	NoSubscribers int64 `json:"no_subscribers"`
+	Delivered     int64 `json:"delivered"`
+	// Coalesced counts events that replaced a queued event of the same type.
+	Coalesced int64 `json:"coalesced"`
+	// Dropped counts events discarded on overflow, including discarded
+	// backlogs.
+	Dropped int64 `json:"dropped"`
+	// Overflows counts publishes that found a full queue.
+	Overflows int64 `json:"overflows"`
+	Resyncs   int64 `json:"resyncs"`
+	// SlowDisconnects counts subscribers disconnected for not draining.
+	SlowDisconnects int64 `json:"slow_disconnects"`
+}
+
+type hubStats struct {
+	published       atomic.Int64
+	noSubscribers   atomic.Int64
+	delivered       atomic.Int64
+	coalesced       atomic.Int64
+	dropped         atomic.Int64
+	overflows       atomic.Int64
+	resyncs         atomic.Int64
+	slowDisconnects atomic.Int64
+}
+
+// Stats returns a snapshot of the hub counters.
+func (s *hubStats) Stats() HubStats {
+	return HubStats{
+		Published:       s.published.Load(),
+		NoSubscribers:   s.noSubscribers.Load(),
+		Delivered:       s.delivered.Load(),
+		Coalesced:       s.coalesced.Load(),
+		Dropped:         s.dropped.Load(),
+		Overflows:       s.overflows.Load(),
+		Resyncs:         s.resyncs.Load(),
+		SlowDisconnects: s.slowDisconnects.Load(),
+	}
+}
+
+// Subscription is one SSE connection registered with a hub. Publishers
+// never block on it: events are queued, and the consumer waits on Ready and
+// then takes everything queued with Drain.
+type Subscription struct {
+	info   ConnectionInfo
+	policy OverflowPolicy
+	stats  *hubStats
+
+	mu        sync.Mutex
+	queue     []Event
+	resync    bool // backlog discarded; a resync event is queued
+	lastDrain time.Time
+	closed    bool
+	err       error
+
+	ready chan struct{}
+	done  chan struct{}
+
+	// release removes the subscription from its hub.
+	release func()
+	once    sync.Once
+}
+
+func newSubscription(userUUID string, opts SubscribeOptions, stats *hubStats) *Subscription {
+	policy := opts.Overflow
+	if policy.Capacity <= 0 {
+		policy = *defaultOverflowPolicy.Load()
+	}
+	now := time.Now()
+	return &Subscription{
+		info: ConnectionInfo{
+			ID:          subscriptionSeq.Add(1),
+			UserUUID:    userUUID,
+			ConnectedAt: now,
+			RemoteAddr:  opts.RemoteAddr,
+			UserAgent:   opts.UserAgent,
+		},
+		policy:    policy,
+		stats:     stats,
+		queue:     make([]Event, 0, policy.Capacity),
+		lastDrain: now,
+		ready:     make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+}
+
+// UserUUID returns the subscribed user.
+func (s *Subscription) UserUUID() string {
+	return s.info.UserUUID
+}
+
+// Info returns the connection metadata.
+func (s *Subscription) Info() ConnectionInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info := s.info
+	info.Queued = len(s.queue)
+	info.Capacity = s.policy.Capacity
+	info.LastDrain = s.lastDrain
+	return info
+}
+
+// Ready is signalled when events are queued.
+func (s *Subscription) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Done is closed when the subscription ends, either by Close or because the
+// hub disconnected it (see Err).
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns ErrSlowConsumer if the hub disconnected the subscriber.
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Drain appends all queued events to dst and returns it. After Done, it
+// returns what was still queued when the hub closed the subscription.
+func (s *Subscription) Drain(dst []Event) []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dst = append(dst, s.queue...)
+	clear(s.queue)
+	s.queue = s.queue[:0]
+	s.resync = false
+	s.lastDrain = time.Now()
+	return dst
+}
+
+// Close unsubscribes. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.shutdown(nil)
+	s.once.Do(func() {
+		if s.release != nil {
+			s.release()
+		}
+	})
+}
+
+// disconnect ends the subscription on behalf of the hub; Err reports err.
+func (s *Subscription) disconnect(err error) {
+	s.shutdown(err)
+	s.Close()
+}
+
+func (s *Subscription) shutdown(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeLocked(err)
+}
+
+func (s *Subscription) closeLocked(err error) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.err = err
+	close(s.done)
+}
+
+func (s *Subscription) signal() {
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// send queues ev without blocking, applying coalescing and the overflow
+// policy.
+func (s *Subscription) send(ev Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	if ev.Coalesce {
+		for i := range s.queue {
+			if s.queue[i].Coalesce && s.queue[i].Type == ev.Type {
+				// Latest state wins; the consumer has not seen the old one.
+				s.queue[i] = ev
+				s.stats.coalesced.Add(1)
+				return
+			}
+		}
+	}
+
+	if !s.resync && len(s.queue) < s.policy.Capacity {
+		s.queue = append(s.queue, ev)
+		s.stats.delivered.Add(1)
+		s.signal()
+		return
+	}
+
+	s.stats.overflows.Add(1)
+	if s.policy.DisconnectAfter > 0 && time.Since(s.lastDrain) > s.policy.DisconnectAfter {
+		s.stats.dropped.Add(int64(len(s.queue)) + 1)
+		s.stats.slowDisconnects.Add(1)
+		clear(s.queue)
+		s.queue = nil
+		s.closeLocked(ErrSlowConsumer)
+		// The hub may hold a lock while publishing; unregister separately.
+		go s.Close()
+		return
+	}
+	if s.resync || s.policy.Action == OverflowDrop {
+		// The client reloads on resync, so nothing after it is needed.
+		s.stats.dropped.Add(1)
+		return
+	}
+
+	s.stats.dropped.Add(int64(len(s.queue)) + 1)
+	s.stats.resyncs.Add(1)
+	clear(s.queue)
+	s.queue = append(s.queue[:0], Event{
+		Type: ResyncEventType,
+		Data: map[string]interface{}{"reason": "overflow"},
+	})
+	s.resync = true
+	s.signal()
+}
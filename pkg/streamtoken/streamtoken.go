@@ -0,0 +1,159 @@
+// Package streamtoken issues and verifies short-lived tokens that authorise
+// one user's notification stream. Browsers cannot set headers on an
+// EventSource, so the stream is opened with the token in the query string
+// instead of the user UUID. Query strings end up in proxy and access logs,
+// so the claims are encrypted rather than just signed and the token reveals
+// nothing but the key ID.
+package streamtoken
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Verification errors.
+var (
+	ErrMalformed    = errors.New("streamtoken: malformed token")
+	ErrUnknownKey   = errors.New("streamtoken: unknown key")
+	ErrBadSignature = errors.New("streamtoken: bad signature")
+	ErrExpired      = errors.New("streamtoken: expired")
+)
+
+// tokenType marks stream tokens so a token issued with the same secret for
+// another purpose cannot be replayed here.
+const tokenType = "notification-stream"
+
+// Key is a secret identified by ID, so keys can be rotated like the SSE
+// bridge signing keys. The AES-256-GCM key that seals tokens is derived from
+// Secret.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+type claims struct {
+	Type      string `json:"typ"`
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Issuer seals tokens with its first key and opens them with any key.
+type Issuer struct {
+	signKey string
+	keys    map[string]cipher.AEAD
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// New constructs an Issuer for tokens valid for ttl.
+func New(keys []Key, ttl time.Duration) (*Issuer, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("streamtoken: no keys")
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("streamtoken: invalid ttl %s", ttl)
+	}
+	i := &Issuer{signKey: keys[0].ID, keys: make(map[string]cipher.AEAD, len(keys)), ttl: ttl, now: time.Now}
+	for n, k := range keys {
+		if k.ID == "" || len(k.Secret) == 0 {
+			return nil, fmt.Errorf("streamtoken: key %d: missing id or secret", n)
+		}
+		if _, dup := i.keys[k.ID]; dup {
+			return nil, fmt.Errorf("streamtoken: key %q: duplicate id", k.ID)
+		}
+		aead, err := newAEAD(k.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("streamtoken: key %q: %w", k.ID, err)
+		}
+		i.keys[k.ID] = aead
+	}
+	return i, nil
+}
+
+// newAEAD derives a dedicated encryption key from secret, so the configured
+// secret can be of any length.
+func newAEAD(secret []byte) (cipher.AEAD, error) {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(tokenType + " encryption"))
+	block, err := aes.NewCipher(h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// TTL returns how long issued tokens are valid.
+func (i *Issuer) TTL() time.Duration {
+	return i.ttl
+}
+
+// Issue returns a token for userUUID and its expiry. The token is the key ID
+// and the sealed claims, both base64url encoded and joined by a dot; the key
+// ID is authenticated as additional data.
+func (i *Issuer) Issue(userUUID string) (string, time.Time, error) {
+	now := i.now()
+	exp := now.Add(i.ttl)
+	raw, err := json.Marshal(claims{
+		Type:      tokenType,
+		Subject:   userUUID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: exp.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	aead := i.keys[i.signKey]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(raw)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", time.Time{}, err
+	}
+	sealed := aead.Seal(nonce, nonce, raw, []byte(i.signKey))
+	token := base64.RawURLEncoding.EncodeToString([]byte(i.signKey)) + "." + base64.RawURLEncoding.EncodeToString(sealed)
+	return token, time.Unix(exp.Unix(), 0), nil
+}
+
+// Verify checks token and returns the user it was issued for and its expiry.
+func (i *Issuer) Verify(token string) (string, time.Time, error) {
+	kid, body, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", time.Time{}, ErrMalformed
+	}
+	rawKID, err := base64.RawURLEncoding.DecodeString(kid)
+	if err != nil {
+		return "", time.Time{}, ErrMalformed
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return "", time.Time{}, ErrMalformed
+	}
+	aead, ok := i.keys[string(rawKID)]
+	if !ok {
+		return "", time.Time{}, ErrUnknownKey
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", time.Time{}, ErrMalformed
+	}
+	raw, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], rawKID)
+	if err != nil {
+		return "", time.Time{}, ErrBadSignature
+	}
+	var c claims
+	if err := json.Unmarshal(raw, &c); err != nil || c.Type != tokenType || c.Subject == "" {
+		return "", time.Time{}, ErrMalformed
+	}
+	exp := time.Unix(c.ExpiresAt, 0)
+	if !i.now().Before(exp) {
+		return "", time.Time{}, ErrExpired
+	}
+	return c.Subject, exp, nil
+}
@@ -0,0 +1,77 @@
+package streamtoken
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIssueAndVerify(t *testing.T) {
+	old := Key{ID: "old", Secret: []byte("old-secret")}
+	cur := Key{ID: "cur", Secret: []byte("cur-secret")}
+	now := time.Unix(1_700_000_000, 0)
+
+	issuer, err := New([]Key{cur, old}, time.Minute)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	issuer.now = func() time.Time { return now }
+	legacy, _ := New([]Key{old}, time.Minute)
+	legacy.now = issuer.now
+	stranger, _ := New([]Key{{ID: "cur", Secret: []byte("other")}}, time.Minute)
+	stranger.now = issuer.now
+
+	token, exp, err := issuer.Issue("user-1")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if !exp.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expiry = %s", exp)
+	}
+	// The subject must not be readable from a logged query string.
+	for _, part := range strings.Split(token, ".") {
+		raw, _ := base64.RawURLEncoding.DecodeString(part)
+		if strings.Contains(string(raw), "user-1") {
+			t.Fatalf("token %q exposes the subject", token)
+		}
+	}
+	oldToken, _, _ := legacy.Issue("user-2")
+	forged, _, _ := stranger.Issue("user-1")
+	// Another user's claims under this token's signature.
+	_, sig, _ := strings.Cut(token, ".")
+	otherPayload, _, _ := strings.Cut(oldToken, ".")
+	tampered := otherPayload + "." + sig
+
+	cases := []struct {
+		name  string
+		token string
+		at    time.Time
+		user  string
+		err   error
+	}{
+		{"valid", token, now, "user-1", nil},
+		{"rotated key", oldToken, now, "user-2", nil},
+		{"expired", token, now.Add(time.Minute), "", ErrExpired},
+		{"forged", forged, now, "", ErrBadSignature},
+		{"tampered", tampered, now, "", ErrBadSignature},
+		{"garbage", "not-a-token", now, "", ErrMalformed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			issuer.now = func() time.Time { return tc.at }
+			user, _, err := issuer.Verify(tc.token)
+			if !errors.Is(err, tc.err) || user != tc.user {
+				t.Fatalf("Verify = %q, %v; want %q, %v", user, err, tc.user, tc.err)
+			}
+		})
+	}
+
+	if _, err := New(nil, time.Minute); err == nil {
+		t.Fatalf("expected error without keys")
+	}
+	if _, err := New([]Key{cur, cur}, time.Minute); err == nil {
+		t.Fatalf("expected error for duplicate key")
+	}
+}
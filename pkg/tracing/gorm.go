@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"errors"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+type gormPlugin struct{}
+
+// GormPlugin starts a client span for every statement run through the GORM
+// DB it is installed on, as a child of the statement's context:
+// db.Use(tracing.GormPlugin()).
+func GormPlugin() gorm.Plugin {
+	return gormPlugin{}
+}
+
+func (gormPlugin) Name() string {
+	return "notification:tracing"
+}
+
+func (gormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("tracing:before_create", gormStart("create")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", gormEnd),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", gormStart("query")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", gormEnd),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", gormStart("update")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", gormEnd),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", gormStart("delete")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", gormEnd),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", gormStart("row")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", gormEnd),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", gormStart("raw")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", gormEnd),
+	)
+}
+
+func gormStart(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		// Statements outside any traced request would only add root spans.
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		_, span := Tracer().Start(ctx, "db "+operation+" "+table,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemNameMySQL,
+				semconv.DBOperationName(operation),
+				semconv.DBCollectionName(table),
+			))
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func gormEnd(db *gorm.DB) {
+	v, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	span.SetAttributes(semconv.DBQueryText(db.Statement.SQL.String()))
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	End(span, err)
+}